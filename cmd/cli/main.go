@@ -12,6 +12,7 @@ import (
 	"log"
 	"math/rand"
 	"os"
+	"time"
 
 	_ "github.com/KalleDK/go-jwt/jwa/ecdsa"
 	_ "github.com/KalleDK/go-jwt/jwa/none"
@@ -122,28 +123,28 @@ func genKeys() (crypto.PrivateKey, crypto.PublicKey) {
 func verify(t []byte, key crypto.PublicKey) {
 	v := jwt.ES256.NewVerifier("ES256-01", key)
 	//vs := jwt.NewVerifiers(true, v)
-	var payload map[string]string
+	var payload jwt.Claims
 	kid, err := jwt.Unmarshal(t, &payload, v)
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	fmt.Println(kid)
-	fmt.Println(payload)
+	fmt.Println(payload.Subject, payload.IssuedAt)
 
 }
 
 func verifyNone(t []byte) {
 	v := jwt.None.NewVerifier("N01", nil)
 	//vs := jwt.NewVerifiers(true, v)
-	var payload map[string]string
+	var payload jwt.Claims
 	kid, err := jwt.Unmarshal(t, &payload, v)
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	fmt.Println(kid)
-	fmt.Println(payload)
+	fmt.Println(payload.Subject, payload.IssuedAt)
 
 }
 
@@ -151,10 +152,14 @@ func sign(priv crypto.PrivateKey) []byte {
 	s := rand.NewSource(0)
 	r := rand.New(s)
 	signer := jwt.ES256.NewSigner("ES256-01", priv)
-	payload := map[string]string{
-		"sub":  "1234567890",
-		"name": "John Doe",
-		"iat":  "1516239022",
+	payload := jwt.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:  "1234567890",
+			IssuedAt: jwt.NewNumericDate(time.Unix(1516239022, 0)),
+		},
+	}
+	if err := payload.Set("name", "John Doe"); err != nil {
+		log.Fatal(err)
 	}
 	b, err := jwt.Marshal(r, payload, signer)
 	if err != nil {
@@ -170,10 +175,14 @@ func sign(priv crypto.PrivateKey) []byte {
 
 func signNone() []byte {
 	signer := jwt.None.NewSigner("N01", nil)
-	payload := map[string]string{
-		"sub":  "1234567890",
-		"name": "John Doe",
-		"iat":  "1516239022",
+	payload := jwt.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:  "1234567890",
+			IssuedAt: jwt.NewNumericDate(time.Unix(1516239022, 0)),
+		},
+	}
+	if err := payload.Set("name", "John Doe"); err != nil {
+		log.Fatal(err)
 	}
 	b, err := jwt.Marshal(nil, payload, signer)
 	if err != nil {
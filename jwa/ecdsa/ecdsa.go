@@ -20,19 +20,23 @@ type verifier struct {
 }
 
 func (v verifier) Verify(signed, signature []byte) error {
-	if len(signature) != int(2*v.keySize) {
-		return ErrMalformedSignature
-	}
-	r := big.NewInt(0).SetBytes(signature[:v.keySize])
-	s := big.NewInt(0).SetBytes(signature[v.keySize:])
-
 	sum := func(b []byte) []byte {
 		hasher := v.hash.New()
 		hasher.Write(b)
 		return hasher.Sum(nil)
 	}(signed)
 
-	if !ecdsa.Verify(v.key, sum, r, s) {
+	return v.VerifyDigest(sum, signature)
+}
+
+func (v verifier) VerifyDigest(digest, signature []byte) error {
+	if len(signature) != int(2*v.keySize) {
+		return ErrMalformedSignature
+	}
+	r := big.NewInt(0).SetBytes(signature[:v.keySize])
+	s := big.NewInt(0).SetBytes(signature[v.keySize:])
+
+	if !ecdsa.Verify(v.key, digest, r, s) {
 		return ErrECDSAVerification
 	}
 
@@ -52,7 +56,11 @@ func (signer signer) Sign(rand io.Reader, unsigned []byte) (signature []byte, er
 		return hasher.Sum(nil)
 	}()
 
-	r, s, err := ecdsa.Sign(rand, signer.key, sum)
+	return signer.SignDigest(rand, sum)
+}
+
+func (signer signer) SignDigest(rand io.Reader, digest []byte) (signature []byte, err error) {
+	r, s, err := ecdsa.Sign(rand, signer.key, digest)
 	if err != nil {
 		return nil, err
 	}
@@ -13,6 +13,18 @@ type Signer interface {
 	Sign(rand io.Reader, unsigned []byte) (signature []byte, err error)
 }
 
+// DigestVerifier is implemented by verifiers that can verify a signature over
+// a digest computed by the caller, which allows hashing streamed input
+type DigestVerifier interface {
+	VerifyDigest(digest, signature []byte) error
+}
+
+// DigestSigner is implemented by signers that can sign a digest computed by
+// the caller, which allows hashing streamed input
+type DigestSigner interface {
+	SignDigest(rand io.Reader, digest []byte) (signature []byte, err error)
+}
+
 type Algoritm interface {
 	Available() bool
 	NewVerifier(key crypto.PublicKey) Verifier
@@ -25,7 +25,11 @@ func (v verifier) Verify(signed, signature []byte) error {
 		return hasher.Sum(nil)
 	}(signed)
 
-	return rsa.VerifyPKCS1v15(v.key, v.hash, sum, signature)
+	return v.VerifyDigest(sum, signature)
+}
+
+func (v verifier) VerifyDigest(digest, signature []byte) error {
+	return rsa.VerifyPKCS1v15(v.key, v.hash, digest, signature)
 }
 
 type signer struct {
@@ -41,7 +45,11 @@ func (signer signer) Sign(rand io.Reader, unsigned []byte) (signature []byte, er
 		return hasher.Sum(nil)
 	}()
 
-	return rsa.SignPKCS1v15(rand, signer.key, signer.hash, sum)
+	return signer.SignDigest(rand, sum)
+}
+
+func (signer signer) SignDigest(rand io.Reader, digest []byte) (signature []byte, err error) {
+	return rsa.SignPKCS1v15(rand, signer.key, signer.hash, digest)
 }
 
 // ErrMalformedSignature is returned when the signature length is wrong
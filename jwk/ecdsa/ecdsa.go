@@ -21,11 +21,28 @@ func getCurveAndAlg(s string) (elliptic.Curve, jwt.Algorithm, error) {
 	switch s {
 	case "P-256":
 		return elliptic.P256(), jwt.ES256, nil
+	case "P-384":
+		return elliptic.P384(), jwt.ES384, nil
+	case "P-521":
+		return elliptic.P521(), jwt.ES512, nil
 	default:
 		return nil, 0, errors.New("invalid curve")
 	}
 }
 
+// getCurveAndCheckAlg is getCurveAndAlg which also requires an alg member, if
+// present, to be the algorithm of the curve
+func getCurveAndCheckAlg(crv string, alg string) (elliptic.Curve, jwt.Algorithm, error) {
+	c, a, err := getCurveAndAlg(crv)
+	if err != nil {
+		return nil, 0, err
+	}
+	if alg != "" && jwt.GetAlgorithm(alg) != a {
+		return nil, 0, errors.New("alg " + alg + " does not match curve " + crv)
+	}
+	return c, a, nil
+}
+
 type Signer struct {
 	hash    crypto.Hash
 	key     *ecdsa.PrivateKey
@@ -35,55 +52,93 @@ type Signer struct {
 }
 
 type signerJSON struct {
-	Curve string `json:"crv"`
-	D     string `json:"d"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv"`
+	D         string `json:"d"`
+	X         string `json:"x"`
+	Y         string `json:"y"`
 }
 
 type verifierJSON struct {
-	Curve string `json:"crv"`
-	X     string `json:"x"`
-	Y     string `json:"y"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	Y         string `json:"y"`
 }
 
 type keyparser struct {
 }
 
-func strtobig(s string) (i *big.Int) {
+// decodeFixed decodes a member which RFC 7518 6.2.1 requires to be the full
+// size of the curve, including leading zeros
+func decodeFixed(name string, s string, c elliptic.Curve) (*big.Int, error) {
+	if s == "" {
+		return nil, errors.New("missing " + name)
+	}
 	b, err := base64.RawURLEncoding.DecodeString(s)
 	if err != nil {
-		fmt.Println(err)
-		return nil
+		return nil, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	if size := (c.Params().BitSize + 7) / 8; len(b) != size {
+		return nil, fmt.Errorf("invalid %s: length %d, expected %d", name, len(b), size)
 	}
-	i = &big.Int{}
-	i.SetBytes(b)
-	return i
+	return new(big.Int).SetBytes(b), nil
 }
 
-func (p keyparser) ParseVerifier(kid string, b []byte) (jwt.Verifier, error) {
+func parsePoint(c elliptic.Curve, xs string, ys string) (*big.Int, *big.Int, error) {
+	x, err := decodeFixed("x", xs, c)
+	if err != nil {
+		return nil, nil, err
+	}
+	y, err := decodeFixed("y", ys, c)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p := c.Params().P
+	if x.Cmp(p) >= 0 || y.Cmp(p) >= 0 || !c.IsOnCurve(x, y) {
+		return nil, nil, errors.New("point is not on curve " + c.Params().Name)
+	}
+	return x, y, nil
+}
+
+func (p keyparser) ParsePublicKey(b []byte) (crypto.PublicKey, error) {
 	var params verifierJSON
 	if err := json.Unmarshal(b, &params); err != nil {
 		return nil, err
 	}
 
-	c, alg, err := getCurveAndAlg(params.Curve)
+	c, _, err := getCurveAndAlg(params.Curve)
 	if err != nil {
 		return nil, err
 	}
 
-	x := strtobig(params.X)
-	if x == nil {
-		return nil, errors.New("invalid X")
-	}
-
-	y := strtobig(params.Y)
-	if y == nil {
-		return nil, errors.New("invalid Y")
+	x, y, err := parsePoint(c, params.X, params.Y)
+	if err != nil {
+		return nil, err
 	}
 
-	key := &ecdsa.PublicKey{
+	return &ecdsa.PublicKey{
 		Curve: c,
 		Y:     y,
 		X:     x,
+	}, nil
+}
+
+func (p keyparser) ParseVerifier(kid string, b []byte) (jwt.Verifier, error) {
+	var params verifierJSON
+	if err := json.Unmarshal(b, &params); err != nil {
+		return nil, err
+	}
+
+	_, alg, err := getCurveAndCheckAlg(params.Curve, params.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := p.ParsePublicKey(b)
+	if err != nil {
+		return nil, err
 	}
 
 	return alg.NewVerifier(kid, key), nil
@@ -95,18 +150,32 @@ func (p keyparser) ParseSigner(kid string, b []byte) (jwt.Signer, error) {
 		return nil, err
 	}
 
-	c, alg, err := getCurveAndAlg(params.Curve)
+	c, alg, err := getCurveAndCheckAlg(params.Curve, params.Algorithm)
 	if err != nil {
 		return nil, err
 	}
 
-	d := strtobig(params.D)
-	if d == nil {
-		return nil, errors.New("invalid D")
+	d, err := decodeFixed("d", params.D, c)
+	if err != nil {
+		return nil, err
+	}
+	if d.Sign() == 0 || d.Cmp(c.Params().N) >= 0 {
+		return nil, errors.New("invalid d: not in the range of curve " + c.Params().Name)
 	}
 
 	x, y := c.ScalarBaseMult(d.Bytes())
 
+	// The public key is optional, but must be the one of d if present
+	if params.X != "" || params.Y != "" {
+		px, py, err := parsePoint(c, params.X, params.Y)
+		if err != nil {
+			return nil, err
+		}
+		if px.Cmp(x) != 0 || py.Cmp(y) != 0 {
+			return nil, errors.New("x and y do not match d")
+		}
+	}
+
 	key := &ecdsa.PrivateKey{
 		PublicKey: ecdsa.PublicKey{
 			Curve: c,
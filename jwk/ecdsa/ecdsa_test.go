@@ -1,10 +1,12 @@
 package ecdsa
 
 import (
+	"encoding/base64"
 	"reflect"
 	"testing"
 
 	_ "crypto/sha256"
+	_ "crypto/sha512"
 
 	_ "github.com/KalleDK/go-jwt/jwa/ecdsa"
 
@@ -21,6 +23,14 @@ func (r norand) Read(b []byte) (int, error) {
 	return len(b), nil
 }
 
+func decode(s string) []byte {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
 func TestParseSigner(t *testing.T) {
 	type args struct {
 		data []byte
@@ -29,7 +39,7 @@ func TestParseSigner(t *testing.T) {
 	tests := []struct {
 		name          string
 		args          args
-		wantSignature []byte
+		publicKey     []byte
 		wantAlgorithm jwt.Algorithm
 		wantKeyID     string
 		wantErr       bool
@@ -45,11 +55,132 @@ func TestParseSigner(t *testing.T) {
 			   }`),
 				data: []byte("flaf"),
 			},
+			publicKey: []byte(`{"kty":"EC",
+				"crv": "P-256",
+				"key_ops": ["verify"],
+				"x": "MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4",
+				"y": "4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyM",
+				"kid":"key01-2020-09-23"
+			   }`),
 			wantKeyID:     "key01-2020-09-23",
-			wantSignature: []byte("'b@R\xe8\a\xc0\x91Fh\x95\x98\xb8\xce$\x8b\x14.bQ\x90G\xfd\x80\aN$4a\xa6\u007f9\xcf`S\x97иj5DE)\x84,\x92\x8d\xf1\x849\xaa\xe0e\xe97\x81ҽY\xf3T\x860\x8f"),
 			wantAlgorithm: jwt.ES256,
 			wantErr:       false,
 		},
+		{
+			name: "P-384",
+			args: args{
+				b: []byte(`{"kty":"EC",
+				"crv": "P-384",
+				"key_ops": ["sign"],
+				"d": "I8HVcW6P73LcpFchlJLN7BOuDljzxV4eaAVZW3J8N7UQ8G5NAUhDpzWA7QYQtfM0",
+				"kid":"key02-p384"
+			   }`),
+				data: []byte("flaf"),
+			},
+			publicKey: []byte(`{"kty":"EC",
+				"crv": "P-384",
+				"key_ops": ["verify"],
+				"x": "-cJsR_GOfQ9l4ttl37IJIlEVSsRBMrERVfU8Y7kD2O_LlFH0Z9CCyn5MiXLGsGOz",
+				"y": "eC_oXhtrEqCv0jIAT2uuL8KGu0lQiFJcHTI2pWnuxCcs0i3qfusnS0dUiosB3pvw",
+				"kid":"key02-p384"
+			   }`),
+			wantKeyID:     "key02-p384",
+			wantAlgorithm: jwt.ES384,
+		},
+		{
+			name: "P-521",
+			args: args{
+				b: []byte(`{"kty":"EC",
+				"crv": "P-521",
+				"alg": "ES512",
+				"key_ops": ["sign"],
+				"d": "AYh7zXO07fjVs87BasHDoW94hQAzHsdpacKoHsmLiKpotZOgRdMS_COUaV6PMjlL-AEFTKX_kQgmMroKmTDDmRu4",
+				"kid":"key03-p521"
+			   }`),
+				data: []byte("flaf"),
+			},
+			publicKey: []byte(`{"kty":"EC",
+				"crv": "P-521",
+				"key_ops": ["verify"],
+				"x": "AAj-CSBA7amhObPyFMrLHReQWgo9F1V3urhWkI9WsP4BGI1Yg4FjmdlhIiVPQDNXLkQxSEks6MPyh0_7rcEXicHe",
+				"y": "AX0H1jkSxuWe3fL3THEH-7B_qFiR8TT0dxJMXRkZs91gX5b5zF6cEbFQ5C62xNJIYEFDNWpctRH_W8OOdmgPjjFM",
+				"kid":"key03-p521"
+			   }`),
+			wantKeyID:     "key03-p521",
+			wantAlgorithm: jwt.ES512,
+		},
+		{
+			name: "alg does not match curve",
+			args: args{
+				b: []byte(`{"kty":"EC",
+				"crv": "P-384",
+				"alg": "ES256",
+				"key_ops": ["sign"],
+				"d": "I8HVcW6P73LcpFchlJLN7BOuDljzxV4eaAVZW3J8N7UQ8G5NAUhDpzWA7QYQtfM0",
+				"kid":"key02-p384"
+			   }`),
+				data: []byte("flaf"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "d not less than order",
+			args: args{
+				b: []byte(`{"kty":"EC",
+				"crv": "P-256",
+				"key_ops": ["sign"],
+				"d": "__________________________________________8"
+			   }`),
+			},
+			wantErr: true,
+		},
+		{
+			name: "d too short",
+			args: args{
+				b: []byte(`{"kty":"EC",
+				"crv": "P-256",
+				"key_ops": ["sign"],
+				"d": "AQ"
+			   }`),
+			},
+			wantErr: true,
+		},
+		{
+			name: "x and y do not match d",
+			args: args{
+				b: []byte(`{"kty":"EC",
+				"crv": "P-256",
+				"key_ops": ["sign"],
+				"d": "870MB6gfuTJ4HtUnUvYMyJpr5eUZNP4Bk43bVdj3eAE",
+				"x": "axfR8uEsQkf4vOblY6RA8ncDfYEt6zOg9KE5RdiYwpY",
+				"y": "T-NC4v4af5uO5-tKfA-eFivOM1drMV7Oy7ZAaDe_UfU"
+			   }`),
+			},
+			wantErr: true,
+		},
+		{
+			name: "x and y match d",
+			args: args{
+				b: []byte(`{"kty":"EC",
+				"crv": "P-256",
+				"key_ops": ["sign"],
+				"d": "870MB6gfuTJ4HtUnUvYMyJpr5eUZNP4Bk43bVdj3eAE",
+				"x": "MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4",
+				"y": "4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyM",
+				"kid":"key01-2020-09-23"
+			   }`),
+				data: []byte("flaf"),
+			},
+			publicKey: []byte(`{"kty":"EC",
+				"crv": "P-256",
+				"key_ops": ["verify"],
+				"x": "MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4",
+				"y": "4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyM",
+				"kid":"key01-2020-09-23"
+			   }`),
+			wantKeyID:     "key01-2020-09-23",
+			wantAlgorithm: jwt.ES256,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -58,6 +189,9 @@ func TestParseSigner(t *testing.T) {
 				t.Errorf("ParseSigner() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			if err != nil {
+				return
+			}
 			gotKeyID := gotSigner.KeyID()
 			if !reflect.DeepEqual(gotKeyID, tt.wantKeyID) {
 				t.Errorf("ParseSigner() = %v, want %v", gotKeyID, tt.wantKeyID)
@@ -66,12 +200,18 @@ func TestParseSigner(t *testing.T) {
 			if !reflect.DeepEqual(gotAlgorithm, tt.wantAlgorithm) {
 				t.Errorf("ParseSigner() = %v, want %v", gotAlgorithm.String(), tt.wantAlgorithm.String())
 			}
+			// ECDSA signatures are randomized, so they are verified rather
+			// than compared
 			gotSignature, err := gotSigner.Sign(norand{}, tt.args.data)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("ParseSigner() error = %v, wantErr %v", err, tt.wantErr)
+			if err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+			verifier, err := jwk.ParseVerifier(tt.publicKey)
+			if err != nil {
+				t.Fatalf("ParseVerifier() error = %v", err)
 			}
-			if !reflect.DeepEqual(gotSignature, tt.wantSignature) {
-				t.Errorf("ParseSigner() = %+#v, want %#v", string(gotSignature), string(tt.wantSignature))
+			if _, err := verifier.Verify(tt.wantAlgorithm, tt.wantKeyID, tt.args.data, gotSignature); err != nil {
+				t.Errorf("Verify() of signature error = %v", err)
 			}
 		})
 	}
@@ -116,6 +256,136 @@ func TestParseVerifier(t *testing.T) {
 			wantAlgorithm:    jwt.ES256,
 			wantErr:          false,
 		},
+		{
+			name: "P-384",
+			args: args{
+				b: []byte(`{
+					"kid": "key02-p384",
+					"kty": "EC",
+					"key_ops": ["verify"],
+					"crv": "P-384",
+					"x": "-cJsR_GOfQ9l4ttl37IJIlEVSsRBMrERVfU8Y7kD2O_LlFH0Z9CCyn5MiXLGsGOz",
+					"y": "eC_oXhtrEqCv0jIAT2uuL8KGu0lQiFJcHTI2pWnuxCcs0i3qfusnS0dUiosB3pvw"
+				  }`),
+				alg:       jwt.ES384,
+				kid:       "key02-p384",
+				data:      []byte("flaf"),
+				signature: decode("pK7pVdrN5WYQA02bVBCuMSlZ8WtUhF8kOCyqwwiumfCmDMrD7LdCxuNOYwNEK5coCbQ-fHd6IKJ7WtkpiqOUTFtqsbLZVC3E5NkT0axrqzr7VCt_3wTA-vfvwOpaswij"),
+			},
+			wantKidUsed:      "key02-p384",
+			wantVerification: true,
+			wantKeyID:        "key02-p384",
+			wantAlgorithm:    jwt.ES384,
+		},
+		{
+			name: "P-521",
+			args: args{
+				b: []byte(`{
+					"kid": "key03-p521",
+					"kty": "EC",
+					"alg": "ES512",
+					"key_ops": ["verify"],
+					"crv": "P-521",
+					"x": "AAj-CSBA7amhObPyFMrLHReQWgo9F1V3urhWkI9WsP4BGI1Yg4FjmdlhIiVPQDNXLkQxSEks6MPyh0_7rcEXicHe",
+					"y": "AX0H1jkSxuWe3fL3THEH-7B_qFiR8TT0dxJMXRkZs91gX5b5zF6cEbFQ5C62xNJIYEFDNWpctRH_W8OOdmgPjjFM"
+				  }`),
+				alg:       jwt.ES512,
+				kid:       "key03-p521",
+				data:      []byte("flaf"),
+				signature: decode("AQVGsCH4vxKCcrl0qksqj9q7NDeqTicaCd3uRJQlaUiw4Opp5h5CFToNGnuiA36kyeGFpkVKJxaQAa_u5Qi_1Fg7ALBOWkv92C6OQYEU-wTx8ikQKNxAo22KMuB6108kYwp83YbO-1n4fpBl4jxsnJ73fWlFXY2Li8cq2p5zLxFi3d_k"),
+			},
+			wantKidUsed:      "key03-p521",
+			wantVerification: true,
+			wantKeyID:        "key03-p521",
+			wantAlgorithm:    jwt.ES512,
+		},
+		{
+			name: "wrong curve signature",
+			args: args{
+				b: []byte(`{
+					"kid": "key02-p384",
+					"kty": "EC",
+					"key_ops": ["verify"],
+					"crv": "P-384",
+					"x": "-cJsR_GOfQ9l4ttl37IJIlEVSsRBMrERVfU8Y7kD2O_LlFH0Z9CCyn5MiXLGsGOz",
+					"y": "eC_oXhtrEqCv0jIAT2uuL8KGu0lQiFJcHTI2pWnuxCcs0i3qfusnS0dUiosB3pvw"
+				  }`),
+				alg:       jwt.ES384,
+				kid:       "key02-p384",
+				data:      []byte("flaf"),
+				signature: decode("AQVGsCH4vxKCcrl0qksqj9q7NDeqTicaCd3uRJQlaUiw4Opp5h5CFToNGnuiA36kyeGFpkVKJxaQAa_u5Qi_1Fg7ALBOWkv92C6OQYEU-wTx8ikQKNxAo22KMuB6108kYwp83YbO-1n4fpBl4jxsnJ73fWlFXY2Li8cq2p5zLxFi3d_k"),
+			},
+			wantVerification: false,
+			wantKeyID:        "key02-p384",
+			wantAlgorithm:    jwt.ES384,
+		},
+		{
+			name: "alg does not match curve",
+			args: args{
+				b: []byte(`{
+					"kid": "key03-p521",
+					"kty": "EC",
+					"alg": "ES384",
+					"key_ops": ["verify"],
+					"crv": "P-521",
+					"x": "AAj-CSBA7amhObPyFMrLHReQWgo9F1V3urhWkI9WsP4BGI1Yg4FjmdlhIiVPQDNXLkQxSEks6MPyh0_7rcEXicHe",
+					"y": "AX0H1jkSxuWe3fL3THEH-7B_qFiR8TT0dxJMXRkZs91gX5b5zF6cEbFQ5C62xNJIYEFDNWpctRH_W8OOdmgPjjFM"
+				  }`),
+			},
+			wantErr: true,
+		},
+		{
+			name: "point not on curve",
+			args: args{
+				b: []byte(`{
+					"kty": "EC",
+					"key_ops": ["verify"],
+					"crv": "P-256",
+					"x": "4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyM",
+					"y": "MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4"
+				  }`),
+			},
+			wantErr: true,
+		},
+		{
+			name: "x too short",
+			args: args{
+				b: []byte(`{
+					"kty": "EC",
+					"key_ops": ["verify"],
+					"crv": "P-256",
+					"x": "AQ",
+					"y": "4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyM"
+				  }`),
+			},
+			wantErr: true,
+		},
+		{
+			name: "y not base64",
+			args: args{
+				b: []byte(`{
+					"kty": "EC",
+					"key_ops": ["verify"],
+					"crv": "P-256",
+					"x": "MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4",
+					"y": "!Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyM"
+				  }`),
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing y",
+			args: args{
+				b: []byte(`{
+					"kty": "EC",
+					"key_ops": ["verify"],
+					"crv": "P-256",
+					"x": "MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4",
+					"y": ""
+				  }`),
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -124,6 +394,9 @@ func TestParseVerifier(t *testing.T) {
 				t.Errorf("ParseVerifier() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			if err != nil {
+				return
+			}
 			gotKeyID := gotVerifier.KeyID()
 			if !reflect.DeepEqual(gotKeyID, tt.wantKeyID) {
 				t.Errorf("ParseVerifier() = %v, want %v", gotKeyID, tt.wantKeyID)
@@ -0,0 +1,63 @@
+package jwk
+
+import (
+	"crypto"
+	"errors"
+
+	"github.com/KalleDK/go-jwt/jwt"
+)
+
+var (
+	// ErrMissingEmbeddedKey is returned when a token has no jwk header
+	ErrMissingEmbeddedKey = errors.New("missing jwk header")
+	// ErrUntrustedKey is returned when the thumbprint of an embedded key is not trusted
+	ErrUntrustedKey = errors.New("jwk is not trusted")
+)
+
+// EmbeddedResolver resolves the verifier of a token from the public key in its
+// jwk header, as used by self-signed request objects and DPoP proofs. Keys with
+// private key material are refused. A key is only trusted if its RFC 7638
+// thumbprint is one of Trusted, or if Trust reports it as trusted, so the
+// zero EmbeddedResolver, like an empty Trusted, rejects every key. Use Trust
+// to accept any key where the key is bound by other means, e.g. a DPoP proof.
+// The thumbprint is used as the key ID of the verifier. The algorithms are
+// restricted by the options given to jwt.UnmarshalResolved
+type EmbeddedResolver struct {
+	// Trusted are the thumbprints of the trusted keys
+	Trusted []string
+	// Trust reports if the key with the thumbprint is trusted, if the
+	// thumbprint is not one of Trusted
+	Trust func(thumbprint string, key crypto.PublicKey) bool
+}
+
+func (r EmbeddedResolver) Resolve(header *jwt.JOSEHeader) (jwt.Verifiers, error) {
+	if len(header.JWK) == 0 {
+		return nil, ErrMissingEmbeddedKey
+	}
+
+	private, err := hasPrivateMembers(header.JWK)
+	if err != nil {
+		return nil, err
+	}
+	if private {
+		return nil, ErrPrivateKeyMaterial
+	}
+
+	thumbprint, err := Thumbprint(header.JWK)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ParsePublicKey(header.JWK)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isin(thumbprint, r.Trusted) && (r.Trust == nil || !r.Trust(thumbprint, key)) {
+		return nil, ErrUntrustedKey
+	}
+
+	if header.Alg() == 0 {
+		return nil, &jwt.UnsupportedAlgorithmError{Algorithm: header.Algorithm}
+	}
+	return jwt.NewVerifierForKey(header.Alg(), thumbprint, key)
+}
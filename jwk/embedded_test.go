@@ -0,0 +1,114 @@
+package jwk
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	_ "crypto/sha256"
+
+	ecdsajwa "github.com/KalleDK/go-jwt/jwa/ecdsa"
+	_ "github.com/KalleDK/go-jwt/jwk/ecdsa"
+	_ "github.com/KalleDK/go-jwt/jwk/rsa"
+	"github.com/KalleDK/go-jwt/jwt"
+)
+
+func TestThumbprint(t *testing.T) {
+	// RFC 7638 Section 3.1
+	b := []byte(`{
+		"kty": "RSA",
+		"n": "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+		"e": "AQAB",
+		"alg": "RS256",
+		"kid": "2011-04-29"
+	}`)
+	got, err := Thumbprint(b)
+	if err != nil {
+		t.Fatalf("Thumbprint() error = %v", err)
+	}
+	if want := "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"; got != want {
+		t.Errorf("Thumbprint() = %v, want %v", got, want)
+	}
+}
+
+func ecJWK(t *testing.T, key *ecdsa.PrivateKey, private bool) json.RawMessage {
+	t.Helper()
+	params := map[string]string{
+		"kty": "EC",
+		"crv": "P-256",
+		"x":   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		"y":   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+	}
+	if private {
+		params["d"] = base64.RawURLEncoding.EncodeToString(key.D.FillBytes(make([]byte, 32)))
+	}
+	b, err := json.Marshal(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestEmbeddedResolver(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicJWK := ecJWK(t, key, false)
+	thumbprint, err := Thumbprint(publicJWK)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	anyKey := EmbeddedResolver{Trust: func(thumbprint string, key crypto.PublicKey) bool { return true }}
+	trustKey := func(thumbprint string, pub crypto.PublicKey) bool {
+		k, ok := pub.(*ecdsa.PublicKey)
+		return ok && k.Equal(&key.PublicKey)
+	}
+
+	tests := []struct {
+		name     string
+		jwk      json.RawMessage
+		resolver EmbeddedResolver
+		wantErr  error
+	}{
+		{name: "any key", jwk: publicJWK, resolver: anyKey},
+		{name: "no trusted keys", jwk: publicJWK, wantErr: ErrUntrustedKey},
+		{name: "trusted key", jwk: publicJWK, resolver: EmbeddedResolver{Trusted: []string{"other", thumbprint}}},
+		{name: "untrusted key", jwk: publicJWK, resolver: EmbeddedResolver{Trusted: []string{"other"}}, wantErr: ErrUntrustedKey},
+		{name: "trusted by callback", jwk: publicJWK, resolver: EmbeddedResolver{Trusted: []string{"other"}, Trust: trustKey}},
+		{name: "untrusted by callback", jwk: ecJWK(t, other, false), resolver: EmbeddedResolver{Trust: trustKey}, wantErr: ErrUntrustedKey},
+		{name: "private key", jwk: ecJWK(t, key, true), resolver: anyKey, wantErr: ErrPrivateKeyMaterial},
+		{name: "missing key", jwk: nil, resolver: anyKey, wantErr: ErrMissingEmbeddedKey},
+		{name: "substituted key", jwk: ecJWK(t, other, false), resolver: anyKey, wantErr: ecdsajwa.ErrECDSAVerification},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := jwt.JOSEHeader{Type: "dpop+jwt", JWK: tt.jwk}
+			b, err := jwt.MarshalWithHeader(rand.Reader, map[string]string{"htm": "POST"}, &header, jwt.ES256.NewSigner("", key))
+			if err != nil {
+				t.Fatalf("MarshalWithHeader() error = %v", err)
+			}
+
+			var got jwt.JOSEHeader
+			var payload map[string]string
+			kid, err := jwt.UnmarshalResolved(b, &payload, &got, tt.resolver, jwt.WithTypes("dpop+jwt"), jwt.WithAlgorithms(jwt.ES256))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UnmarshalResolved() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && kid != thumbprint {
+				t.Errorf("UnmarshalResolved() kid = %v, want %v", kid, thumbprint)
+			}
+		})
+	}
+}
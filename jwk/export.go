@@ -0,0 +1,201 @@
+package jwk
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/KalleDK/go-jwt/jwt"
+)
+
+var (
+	// ErrUnsupportedKey is returned when a key can not be represented as a JWK
+	ErrUnsupportedKey = errors.New("unsupported key type")
+	// ErrKeyUnavailable is returned when a signer or verifier does not expose its key
+	ErrKeyUnavailable = errors.New("key is unavailable")
+)
+
+// Key is an EC or RSA key marshaled as a JWK (RFC 7517). Key is one of
+// *ecdsa.PublicKey, *ecdsa.PrivateKey, *rsa.PublicKey or *rsa.PrivateKey, and
+// private keys are marshaled with their private members
+type Key struct {
+	Key       interface{}
+	KeyID     string
+	Algorithm jwt.Algorithm
+	Use       string
+	KeyOps    []string
+}
+
+// Set is a JWK set (RFC 7517 5), e.g. for publishing the public keys of an issuer
+type Set struct {
+	Keys []Key `json:"keys"`
+}
+
+type keyJSON struct {
+	KeyType   string   `json:"kty"`
+	KeyID     string   `json:"kid,omitempty"`
+	Use       string   `json:"use,omitempty"`
+	KeyOps    []string `json:"key_ops,omitempty"`
+	Algorithm string   `json:"alg,omitempty"`
+
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	D  string `json:"d,omitempty"`
+	P  string `json:"p,omitempty"`
+	Q  string `json:"q,omitempty"`
+	DP string `json:"dp,omitempty"`
+	DQ string `json:"dq,omitempty"`
+	QI string `json:"qi,omitempty"`
+}
+
+// FromSigner returns the private key of a signer created by
+// jwt.Algorithm.NewSigner or ParseSigner, for use in signing
+func FromSigner(s jwt.Signer) (Key, error) {
+	ks, ok := s.(jwt.KeySigner)
+	if !ok {
+		return Key{}, ErrKeyUnavailable
+	}
+	return Key{
+		Key:       ks.PrivateKey(),
+		KeyID:     s.KeyID(),
+		Algorithm: s.Algorithm(),
+		Use:       "sig",
+		KeyOps:    []string{"sign"},
+	}, nil
+}
+
+// FromVerifier returns the public key of a verifier created by
+// jwt.Algorithm.NewVerifier or ParseVerifier, for use in verifying
+func FromVerifier(v jwt.Verifier) (Key, error) {
+	kv, ok := v.(jwt.KeyVerifier)
+	if !ok {
+		return Key{}, ErrKeyUnavailable
+	}
+	return Key{
+		Key:       kv.PublicKey(),
+		KeyID:     v.KeyID(),
+		Algorithm: v.Algorithm(),
+		Use:       "sig",
+		KeyOps:    []string{"verify"},
+	}, nil
+}
+
+// Public returns the public part of the key. The sign key operation becomes
+// verify
+func (k Key) Public() Key {
+	if priv, ok := k.Key.(crypto.Signer); ok {
+		k.Key = priv.Public()
+	}
+
+	if k.KeyOps != nil {
+		ops := []string{}
+		for _, op := range k.KeyOps {
+			if op == "sign" {
+				op = "verify"
+			}
+			if op == "verify" && !isin(op, ops) {
+				ops = append(ops, op)
+			}
+		}
+		k.KeyOps = ops
+	}
+	return k
+}
+
+func (k Key) MarshalJSON() ([]byte, error) {
+	params := keyJSON{
+		KeyID:  k.KeyID,
+		Use:    k.Use,
+		KeyOps: k.KeyOps,
+	}
+	if k.Algorithm != 0 {
+		params.Algorithm = k.Algorithm.String()
+	}
+
+	switch key := k.Key.(type) {
+	case *ecdsa.PublicKey:
+		if err := params.setEC(key); err != nil {
+			return nil, err
+		}
+	case *ecdsa.PrivateKey:
+		if err := params.setEC(&key.PublicKey); err != nil {
+			return nil, err
+		}
+		if key.D.BitLen() > key.Curve.Params().BitSize {
+			return nil, ErrUnsupportedKey
+		}
+		params.D = encodeFixed(key.D, curveSize(key.Curve))
+	case *rsa.PublicKey:
+		params.setRSA(key)
+	case *rsa.PrivateKey:
+		if len(key.Primes) != 2 {
+			return nil, ErrUnsupportedKey
+		}
+		params.setRSA(&key.PublicKey)
+		precomputed := key.Precomputed
+		if precomputed.Dp == nil {
+			// Precompute modifies the key, so it is done on a copy
+			copied := *key
+			copied.Precompute()
+			precomputed = copied.Precomputed
+		}
+		params.D = encode(key.D)
+		params.P = encode(key.Primes[0])
+		params.Q = encode(key.Primes[1])
+		params.DP = encode(precomputed.Dp)
+		params.DQ = encode(precomputed.Dq)
+		params.QI = encode(precomputed.Qinv)
+	default:
+		return nil, ErrUnsupportedKey
+	}
+
+	return json.Marshal(params)
+}
+
+func (p *keyJSON) setEC(key *ecdsa.PublicKey) error {
+	switch key.Curve.Params().Name {
+	case "P-256", "P-384", "P-521":
+	default:
+		return ErrUnsupportedKey
+	}
+	bits := key.Curve.Params().BitSize
+	if key.X.BitLen() > bits || key.Y.BitLen() > bits {
+		return ErrUnsupportedKey
+	}
+	size := curveSize(key.Curve)
+	p.KeyType = "EC"
+	p.Curve = key.Curve.Params().Name
+	p.X = encodeFixed(key.X, size)
+	p.Y = encodeFixed(key.Y, size)
+	return nil
+}
+
+func (p *keyJSON) setRSA(key *rsa.PublicKey) {
+	p.KeyType = "RSA"
+	p.N = encode(key.N)
+	p.E = encode(big.NewInt(int64(key.E)))
+}
+
+// curveSize is the length of the coordinates and private keys of the curve
+// (RFC 7518 6.2.1.2)
+func curveSize(c elliptic.Curve) int {
+	return (c.Params().BitSize + 7) / 8
+}
+
+func encode(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+func encodeFixed(i *big.Int, size int) string {
+	return base64.RawURLEncoding.EncodeToString(i.FillBytes(make([]byte, size)))
+}
@@ -0,0 +1,121 @@
+package jwk
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	_ "github.com/KalleDK/go-jwt/jwa/rsa"
+	"github.com/KalleDK/go-jwt/jwt"
+)
+
+func TestKeyRoundTrip(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		signer jwt.Signer
+	}{
+		{name: "EC", signer: jwt.ES256.NewSigner("ec", ecKey)},
+		{name: "RSA", signer: jwt.RS256.NewSigner("rsa", rsaKey)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			private, err := FromSigner(tt.signer)
+			if err != nil {
+				t.Fatalf("FromSigner() error = %v", err)
+			}
+			privateJSON, err := json.Marshal(private)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			publicJSON, err := json.Marshal(private.Public())
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if private, err := hasPrivateMembers(publicJSON); err != nil || private {
+				t.Fatalf("Public() = %s", publicJSON)
+			}
+
+			signer, err := ParseSigner(privateJSON)
+			if err != nil {
+				t.Fatalf("ParseSigner(%s) error = %v", privateJSON, err)
+			}
+			verifier, err := ParseVerifier(publicJSON)
+			if err != nil {
+				t.Fatalf("ParseVerifier(%s) error = %v", publicJSON, err)
+			}
+			if signer.KeyID() != tt.signer.KeyID() || signer.Algorithm() != tt.signer.Algorithm() || verifier.Algorithm() != tt.signer.Algorithm() {
+				t.Errorf("parsed kid %q alg %v, verifier alg %v", signer.KeyID(), signer.Algorithm(), verifier.Algorithm())
+			}
+
+			b, err := jwt.Marshal(rand.Reader, map[string]string{"sub": "1234567890"}, signer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var payload map[string]string
+			if _, err := jwt.Unmarshal(b, &payload, verifier); err != nil {
+				t.Errorf("Unmarshal() error = %v", err)
+			}
+
+			exported, err := FromVerifier(verifier)
+			if err != nil {
+				t.Fatalf("FromVerifier() error = %v", err)
+			}
+			again, err := json.Marshal(exported)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(again) != string(publicJSON) {
+				t.Errorf("FromVerifier() = %s, want %s", again, publicJSON)
+			}
+		})
+	}
+}
+
+func TestKeyMarshalJSON(t *testing.T) {
+	// A P-256 key with a 31 byte x coordinate must still encode it in 32 bytes
+	var key *ecdsa.PrivateKey
+	for key == nil || key.X.BitLen() > 248 {
+		var err error
+		if key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	k, err := FromSigner(jwt.ES256.NewSigner("short", key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var params keyJSON
+	if err := json.Unmarshal(b, &params); err != nil {
+		t.Fatal(err)
+	}
+	for name, member := range map[string]string{"x": params.X, "y": params.Y, "d": params.D} {
+		if decoded, err := base64.RawURLEncoding.DecodeString(member); err != nil || len(decoded) != 32 {
+			t.Errorf("%s = %q, want 32 bytes", name, member)
+		}
+	}
+	if params.KeyType != "EC" || params.Curve != "P-256" || params.KeyID != "short" || params.Algorithm != "ES256" || params.Use != "sig" {
+		t.Errorf("MarshalJSON() = %s", b)
+	}
+
+	if _, err := json.Marshal(Key{Key: "secret"}); err == nil {
+		t.Errorf("MarshalJSON() of unsupported key succeeded")
+	}
+}
@@ -27,10 +27,6 @@ func (s signer) KeyID() string {
 	return s.kid
 }
 
-func (s signer) MarshalJSON() ([]byte, error) {
-	return nil, nil
-}
-
 type jwkheader struct {
 	KeyType string   `json:"kty"`
 	KeyID   string   `json:"kid"`
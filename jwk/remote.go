@@ -0,0 +1,382 @@
+package jwk
+
+import (
+	"container/list"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KalleDK/go-jwt/jwt"
+)
+
+var (
+	// ErrMissingKeyReference is returned when a token has neither a jku nor a resolvable x5u header
+	ErrMissingKeyReference = errors.New("missing jku or x5u header")
+	// ErrOriginNotAllowed is returned when a jku or x5u URL is not an allowed HTTPS origin
+	ErrOriginNotAllowed = errors.New("origin is not allowed")
+	// ErrKeyNotFound is returned when a JWK set has no usable key for a token
+	ErrKeyNotFound = errors.New("no matching key in jwk set")
+)
+
+const (
+	// DefaultRemoteTTL is how long a RemoteResolver caches fetched keys by default
+	DefaultRemoteTTL = 10 * time.Minute
+	// DefaultRemoteTimeout limits the time of a fetch if RemoteResolver.Client is nil
+	DefaultRemoteTimeout = 10 * time.Second
+	// DefaultRemoteMaxSize limits the size of fetched JWK sets and certificate
+	// chains by default
+	DefaultRemoteMaxSize = 1 << 20
+	// DefaultRemoteMaxEntries is how many responses a RemoteResolver caches by
+	// default
+	DefaultRemoteMaxEntries = 64
+	// maxRemoteRedirects is the number of redirects followed, as by http.Client
+	maxRemoteRedirects = 10
+)
+
+// RemoteResolver resolves the verifiers of a token from the JWK set referenced
+// by its jku header, or the certificate chain referenced by its x5u header.
+// Only HTTPS URLs with one of the allowed origins are fetched, and the
+// responses are cached. Concurrent resolutions of the same URL share a single
+// fetch, and the least recently used response is evicted when the cache is
+// full. Certificate chains are only resolved if Certificates
+// is set, and are validated like jwt.CertificateResolver does, so
+// Certificates.Roots and Certificates.KeyUsages must be set
+type RemoteResolver struct {
+	// Origins are the allowed origins, e.g. "https://issuer.example.com"
+	Origins []string
+	// Client fetches the URLs, a client with DefaultRemoteTimeout is used if
+	// nil. Redirects are only followed to allowed origins
+	Client *http.Client
+	// TTL is how long responses are cached, DefaultRemoteTTL is used if zero
+	TTL time.Duration
+	// MaxSize limits the size of responses, DefaultRemoteMaxSize is used if zero
+	MaxSize int64
+	// MaxEntries limits the number of cached responses,
+	// DefaultRemoteMaxEntries is used if zero
+	MaxEntries int
+	// Certificates are the options used to validate x5u certificate chains
+	Certificates *x509.VerifyOptions
+
+	mu       sync.Mutex
+	cache    map[string]*list.Element
+	lru      *list.List
+	inflight map[string]*remoteCall
+}
+
+type remoteEntry struct {
+	key     string
+	keys    []json.RawMessage
+	certs   []*x509.Certificate
+	expires time.Time
+}
+
+// remoteCall is a fetch in progress, which other resolutions of the same URL
+// wait for
+type remoteCall struct {
+	done  chan struct{}
+	entry remoteEntry
+	err   error
+}
+
+type jwkSet struct {
+	Keys []json.RawMessage `json:"keys"`
+}
+
+type jwkUse struct {
+	Algorithm string   `json:"alg"`
+	KeyID     string   `json:"kid"`
+	Use       string   `json:"use"`
+	KeyOps    []string `json:"key_ops"`
+}
+
+func (r *RemoteResolver) Resolve(header *jwt.JOSEHeader) (jwt.Verifiers, error) {
+	switch {
+	case header.JWKSetURL != "":
+		return r.resolveJWKSet(header)
+	case header.X509URL != "" && r.Certificates != nil:
+		return r.resolveCertificates(header)
+	default:
+		return nil, ErrMissingKeyReference
+	}
+}
+
+func (r *RemoteResolver) resolveJWKSet(header *jwt.JOSEHeader) (jwt.Verifiers, error) {
+	alg := header.Alg()
+	if alg == 0 {
+		return nil, &jwt.UnsupportedAlgorithmError{Algorithm: header.Algorithm}
+	}
+
+	entry, err := r.fetch("jku", header.JWKSetURL, parseJWKSet)
+	if err != nil {
+		return nil, err
+	}
+
+	kid := header.Kid()
+
+	verifiers := []jwt.Verifier{}
+	for _, b := range entry.keys {
+		var use jwkUse
+		if err := json.Unmarshal(b, &use); err != nil {
+			continue
+		}
+		if kid != "" && use.KeyID != kid {
+			continue
+		}
+		if use.Use != "" && use.Use != "sig" {
+			continue
+		}
+		if use.KeyOps != nil && !isin("verify", use.KeyOps) {
+			continue
+		}
+		if use.Algorithm != "" && jwt.GetAlgorithm(use.Algorithm) != alg {
+			continue
+		}
+		if private, err := hasPrivateMembers(b); err != nil || private {
+			continue
+		}
+
+		key, err := ParsePublicKey(b)
+		if err != nil {
+			continue
+		}
+		v, err := jwt.NewVerifierForKey(alg, use.KeyID, key)
+		if err != nil {
+			continue
+		}
+		verifiers = append(verifiers, v)
+	}
+
+	if len(verifiers) == 0 {
+		return nil, ErrKeyNotFound
+	}
+
+	return jwt.NewVerifiers(kid != "", verifiers...), nil
+}
+
+func (r *RemoteResolver) resolveCertificates(header *jwt.JOSEHeader) (jwt.Verifiers, error) {
+	// Checked before fetching, the system roots must never be used
+	if r.Certificates.Roots == nil {
+		return nil, jwt.ErrMissingTrustRoots
+	}
+
+	entry, err := r.fetch("x5u", header.X509URL, parseCertificateChain)
+	if err != nil {
+		return nil, err
+	}
+
+	chained := *header
+	chained.X509CertChain = make([]string, len(entry.certs))
+	for i, cert := range entry.certs {
+		chained.X509CertChain[i] = base64.StdEncoding.EncodeToString(cert.Raw)
+	}
+
+	return jwt.CertificateResolver{Options: *r.Certificates}.Resolve(&chained)
+}
+
+// fetch returns the cached response of rawurl, or fetches and parses it. The
+// cache is keyed by the header and the normalized URL
+func (r *RemoteResolver) fetch(header string, rawurl string, parse func(b []byte) (remoteEntry, error)) (remoteEntry, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil || !r.allowed(rawurl) {
+		return remoteEntry{}, ErrOriginNotAllowed
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	rawurl = u.String()
+	key := header + " " + rawurl
+
+	r.mu.Lock()
+	if entry, ok := r.cached(key, time.Now()); ok {
+		r.mu.Unlock()
+		return entry, nil
+	}
+	if call, ok := r.inflight[key]; ok {
+		r.mu.Unlock()
+		<-call.done
+		return call.entry, call.err
+	}
+	call := &remoteCall{done: make(chan struct{})}
+	if r.inflight == nil {
+		r.inflight = map[string]*remoteCall{}
+	}
+	r.inflight[key] = call
+	r.mu.Unlock()
+
+	call.entry, call.err = r.load(rawurl, parse)
+
+	r.mu.Lock()
+	delete(r.inflight, key)
+	if call.err == nil {
+		call.entry.key = key
+		r.store(call.entry)
+	}
+	r.mu.Unlock()
+	close(call.done)
+
+	return call.entry, call.err
+}
+
+// cached returns the unexpired entry of key, and marks it as recently used.
+// r.mu must be held
+func (r *RemoteResolver) cached(key string, now time.Time) (remoteEntry, bool) {
+	e, ok := r.cache[key]
+	if !ok {
+		return remoteEntry{}, false
+	}
+	entry := e.Value.(remoteEntry)
+	if !now.Before(entry.expires) {
+		r.lru.Remove(e)
+		delete(r.cache, key)
+		return remoteEntry{}, false
+	}
+	r.lru.MoveToFront(e)
+	return entry, true
+}
+
+// store caches entry, evicting the least recently used entries if the cache
+// is full. r.mu must be held
+func (r *RemoteResolver) store(entry remoteEntry) {
+	if r.cache == nil {
+		r.cache = map[string]*list.Element{}
+		r.lru = list.New()
+	}
+	if e, ok := r.cache[entry.key]; ok {
+		r.lru.Remove(e)
+	}
+	r.cache[entry.key] = r.lru.PushFront(entry)
+
+	maxEntries := r.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = DefaultRemoteMaxEntries
+	}
+	for r.lru.Len() > maxEntries {
+		oldest := r.lru.Back()
+		r.lru.Remove(oldest)
+		delete(r.cache, oldest.Value.(remoteEntry).key)
+	}
+}
+
+// load fetches and parses rawurl
+func (r *RemoteResolver) load(rawurl string, parse func(b []byte) (remoteEntry, error)) (remoteEntry, error) {
+	now := time.Now()
+
+	resp, err := r.client().Get(rawurl)
+	if err != nil {
+		return remoteEntry{}, err
+	}
+	defer resp.Body.Close()
+
+	// Redirects are checked by the client, but the final URL is checked again
+	// in case the client has a Transport following redirects itself
+	if !r.allowed(resp.Request.URL.String()) {
+		return remoteEntry{}, ErrOriginNotAllowed
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return remoteEntry{}, errors.New("jwk: fetching " + rawurl + " returned status " + strconv.Itoa(resp.StatusCode))
+	}
+
+	maxSize := r.MaxSize
+	if maxSize == 0 {
+		maxSize = DefaultRemoteMaxSize
+	}
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return remoteEntry{}, err
+	}
+	if int64(len(b)) > maxSize {
+		return remoteEntry{}, errors.New("jwk: response from " + rawurl + " is too large")
+	}
+
+	entry, err := parse(b)
+	if err != nil {
+		return remoteEntry{}, err
+	}
+
+	ttl := r.TTL
+	if ttl == 0 {
+		ttl = DefaultRemoteTTL
+	}
+	entry.expires = now.Add(ttl)
+	return entry, nil
+}
+
+// client returns a copy of Client which refuses to follow redirects to origins
+// which are not allowed, before they are requested
+func (r *RemoteResolver) client() *http.Client {
+	client := http.Client{Timeout: DefaultRemoteTimeout}
+	if r.Client != nil {
+		client = *r.Client
+	}
+
+	next := client.CheckRedirect
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !r.allowed(req.URL.String()) {
+			return ErrOriginNotAllowed
+		}
+		if next != nil {
+			return next(req, via)
+		}
+		if len(via) >= maxRemoteRedirects {
+			return errors.New("jwk: stopped after " + strconv.Itoa(maxRemoteRedirects) + " redirects")
+		}
+		return nil
+	}
+	return &client
+}
+
+func (r *RemoteResolver) allowed(rawurl string) bool {
+	u, err := url.Parse(rawurl)
+	if err != nil || u.Scheme != "https" || u.User != nil || u.Host == "" {
+		return false
+	}
+	origin := "https://" + strings.ToLower(u.Host)
+	for _, o := range r.Origins {
+		if strings.ToLower(strings.TrimSuffix(o, "/")) == origin {
+			return true
+		}
+	}
+	return false
+}
+
+func parseJWKSet(b []byte) (remoteEntry, error) {
+	var set jwkSet
+	if err := json.Unmarshal(b, &set); err != nil {
+		return remoteEntry{}, err
+	}
+	return remoteEntry{keys: set.Keys}, nil
+}
+
+func parseCertificateChain(b []byte) (remoteEntry, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, b = pem.Decode(b)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return remoteEntry{}, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return remoteEntry{}, jwt.ErrMissingCertificateChain
+	}
+	return remoteEntry{certs: certs}, nil
+}
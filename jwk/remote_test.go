@@ -0,0 +1,227 @@
+package jwk
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/KalleDK/go-jwt/jwt"
+)
+
+func TestRemoteResolver(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var member map[string]string
+	if err := json.Unmarshal(ecJWK(t, key, false), &member); err != nil {
+		t.Fatal(err)
+	}
+	member["kid"] = "partner-1"
+	member["use"] = "sig"
+	set, err := json.Marshal(map[string]interface{}{"keys": []interface{}{member}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "partner"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+
+	var fetches int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/jwks.json", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		w.Write(set)
+	})
+	mux.HandleFunc("/chain.pem", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		pem.Encode(w, &pem.Block{Type: "CERTIFICATE", Bytes: der})
+	})
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+
+	// internal is not an allowed origin, and must never be requested
+	var internalFetches int32
+	internal := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&internalFetches, 1)
+		w.Write(set)
+	}))
+	defer internal.Close()
+	mux.HandleFunc("/redirect.json", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, internal.URL+"/jwks.json", http.StatusFound)
+	})
+
+	resolver := &RemoteResolver{
+		Origins:      []string{server.URL},
+		Client:       server.Client(),
+		Certificates: &x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning}},
+	}
+
+	tests := []struct {
+		name        string
+		header      jwt.JOSEHeader
+		kid         string
+		wantErr     error
+		wantFetches int32
+	}{
+		{name: "jku", header: jwt.JOSEHeader{JWKSetURL: server.URL + "/jwks.json"}, kid: "partner-1", wantFetches: 1},
+		{name: "jku cached", header: jwt.JOSEHeader{JWKSetURL: server.URL + "/jwks.json"}, kid: "partner-1", wantFetches: 1},
+		{name: "unknown kid", header: jwt.JOSEHeader{JWKSetURL: server.URL + "/jwks.json"}, kid: "partner-2", wantErr: ErrKeyNotFound, wantFetches: 1},
+		{name: "x5u", header: jwt.JOSEHeader{X509URL: server.URL + "/chain.pem"}, kid: "partner-1", wantFetches: 2},
+		{name: "other origin", header: jwt.JOSEHeader{JWKSetURL: "https://attacker.example.com/jwks.json"}, kid: "partner-1", wantErr: ErrOriginNotAllowed, wantFetches: 2},
+		{name: "plain http", header: jwt.JOSEHeader{JWKSetURL: "http" + server.URL[len("https"):] + "/jwks.json"}, kid: "partner-1", wantErr: ErrOriginNotAllowed, wantFetches: 2},
+		{name: "redirect to other origin", header: jwt.JOSEHeader{JWKSetURL: server.URL + "/redirect.json"}, kid: "partner-1", wantErr: ErrOriginNotAllowed, wantFetches: 2},
+		{name: "no reference", header: jwt.JOSEHeader{}, kid: "partner-1", wantErr: ErrMissingKeyReference, wantFetches: 2},
+	}
+	t.Run("x5u without roots", func(t *testing.T) {
+		systemRoots := &RemoteResolver{
+			Origins:      []string{server.URL},
+			Client:       server.Client(),
+			Certificates: &x509.VerifyOptions{KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}},
+		}
+		header := jwt.JOSEHeader{X509URL: server.URL + "/chain.pem"}
+		before := atomic.LoadInt32(&fetches)
+		if _, err := systemRoots.Resolve(&header); !errors.Is(err, jwt.ErrMissingTrustRoots) {
+			t.Errorf("Resolve() error = %v, want %v", err, jwt.ErrMissingTrustRoots)
+		}
+		if n := atomic.LoadInt32(&fetches); n != before {
+			t.Errorf("Resolve() fetched %v times without roots", n-before)
+		}
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := tt.header
+			b, err := jwt.MarshalWithHeader(rand.Reader, map[string]string{"sub": "partner"}, &header, jwt.ES256.NewSigner(tt.kid, key))
+			if err != nil {
+				t.Fatalf("MarshalWithHeader() error = %v", err)
+			}
+
+			var got jwt.JOSEHeader
+			var payload map[string]string
+			_, err = jwt.UnmarshalResolved(b, &payload, &got, resolver, jwt.WithTypes(""), jwt.WithAlgorithms(jwt.ES256))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("UnmarshalResolved() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if n := atomic.LoadInt32(&fetches); n != tt.wantFetches {
+				t.Errorf("UnmarshalResolved() fetches = %v, want %v", n, tt.wantFetches)
+			}
+			if n := atomic.LoadInt32(&internalFetches); n != 0 {
+				t.Errorf("UnmarshalResolved() fetched %v times from a redirect to another origin", n)
+			}
+		})
+	}
+}
+
+func TestRemoteResolverCache(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set := []byte(`{"keys":[` + string(ecJWK(t, key, false)) + `]}`)
+
+	var fetches int32
+	release := make(chan struct{})
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		<-release
+		w.Write(set)
+	}))
+	defer server.Close()
+
+	resolver := &RemoteResolver{
+		Origins:    []string{server.URL},
+		Client:     server.Client(),
+		MaxEntries: 4,
+	}
+	resolve := func(rawurl string) error {
+		_, err := resolver.Resolve(&jwt.JOSEHeader{Algorithm: "ES256", JWKSetURL: rawurl})
+		return err
+	}
+
+	t.Run("concurrent fetches are shared", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := resolve(server.URL + "/jwks.json"); err != nil {
+					t.Errorf("Resolve() error = %v", err)
+				}
+			}()
+		}
+		for atomic.LoadInt32(&fetches) == 0 {
+			runtime.Gosched()
+		}
+		close(release)
+		wg.Wait()
+		if n := atomic.LoadInt32(&fetches); n != 1 {
+			t.Errorf("Resolve() fetches = %v, want 1", n)
+		}
+	})
+
+	t.Run("normalized", func(t *testing.T) {
+		if err := resolve(strings.ToUpper(server.URL[:5]) + server.URL[5:] + "/jwks.json#key"); err != nil {
+			t.Errorf("Resolve() error = %v", err)
+		}
+		if n := atomic.LoadInt32(&fetches); n != 1 {
+			t.Errorf("Resolve() fetches = %v, want 1", n)
+		}
+	})
+
+	t.Run("bounded", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			if err := resolve(server.URL + "/jwks.json?n=" + strconv.Itoa(i)); err != nil {
+				t.Errorf("Resolve() error = %v", err)
+			}
+		}
+		if n := atomic.LoadInt32(&fetches); n != 11 {
+			t.Errorf("Resolve() fetches = %v, want 11", n)
+		}
+		if n := len(resolver.cache); n != resolver.MaxEntries || resolver.lru.Len() != n {
+			t.Errorf("cache entries = %v, want %v", n, resolver.MaxEntries)
+		}
+
+		// The most recently used entries are kept
+		if err := resolve(server.URL + "/jwks.json?n=9"); err != nil {
+			t.Errorf("Resolve() error = %v", err)
+		}
+		if n := atomic.LoadInt32(&fetches); n != 11 {
+			t.Errorf("Resolve() fetches = %v, want 11", n)
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package rsa
 
 import (
+	"crypto"
 	"crypto/rsa"
 	"encoding/base64"
 	"encoding/json"
@@ -36,50 +37,80 @@ type signer struct {
 type keyparser struct {
 }
 
-func strtobig(s string) (i *big.Int) {
+// decode decodes a required member of the key
+func decode(name string, s string) (*big.Int, error) {
+	if s == "" {
+		return nil, errors.New("missing " + name)
+	}
 	b, err := base64.RawURLEncoding.DecodeString(s)
 	if err != nil {
-		fmt.Println(err)
-		return nil
+		return nil, fmt.Errorf("invalid %s: %w", name, err)
 	}
-	i = &big.Int{}
-	i.SetBytes(b)
-	return i
+	return new(big.Int).SetBytes(b), nil
+}
+
+// getAlgorithm returns the RSA algorithm named by alg
+func getAlgorithm(alg string) (jwt.Algorithm, error) {
+	switch a := jwt.GetAlgorithm(alg); a {
+	case jwt.RS256, jwt.RS384, jwt.RS512:
+		return a, nil
+	}
+	if alg == "" {
+		return 0, errors.New("missing alg")
+	}
+	return 0, errors.New("alg " + alg + " is not an RSA algorithm")
 }
 
 const uintSize = 32 << (^uint(0) >> 32 & 1) // 32 or 64
 const maxInt = 1<<(uintSize-1) - 1
 
-func (p keyparser) ParseVerifier(kid string, b []byte) (jwt.Verifier, error) {
-	var params verifier
-	if err := json.Unmarshal(b, &params); err != nil {
+func parsePublicKey(es string, ns string) (*rsa.PublicKey, error) {
+	eb, err := decode("e", es)
+	if err != nil {
 		return nil, err
 	}
+	if !eb.IsInt64() || eb.Int64() > maxInt || eb.Int64() < 2 {
+		return nil, errors.New("invalid e")
+	}
 
-	eb := strtobig(params.E)
-	if eb == nil {
-		return nil, errors.New("invalid E")
+	n, err := decode("n", ns)
+	if err != nil {
+		return nil, err
 	}
-	if !eb.IsInt64() {
-		return nil, errors.New("invalid E")
+	if n.Sign() == 0 {
+		return nil, errors.New("invalid n")
 	}
-	ei := eb.Int64()
-	if ei > maxInt {
-		return nil, errors.New("invalid E")
+
+	return &rsa.PublicKey{
+		E: int(eb.Int64()),
+		N: n,
+	}, nil
+}
+
+func (p keyparser) ParsePublicKey(b []byte) (crypto.PublicKey, error) {
+	var params verifier
+	if err := json.Unmarshal(b, &params); err != nil {
+		return nil, err
 	}
-	e := int(ei)
 
-	n := strtobig(params.N)
-	if n == nil {
-		return nil, errors.New("invalid N")
+	return parsePublicKey(params.E, params.N)
+}
+
+func (p keyparser) ParseVerifier(kid string, b []byte) (jwt.Verifier, error) {
+	var params verifier
+	if err := json.Unmarshal(b, &params); err != nil {
+		return nil, err
 	}
 
-	key := &rsa.PublicKey{
-		E: e,
-		N: n,
+	alg, err := getAlgorithm(params.Algoritm)
+	if err != nil {
+		return nil, err
 	}
 
-	alg := jwt.GetAlgorithm(params.Algoritm)
+	key, err := p.ParsePublicKey(b)
+	if err != nil {
+		return nil, err
+	}
 
 	return alg.NewVerifier(kid, key), nil
 }
@@ -87,71 +118,69 @@ func (p keyparser) ParseVerifier(kid string, b []byte) (jwt.Verifier, error) {
 func (kp keyparser) ParseSigner(kid string, b []byte) (jwt.Signer, error) {
 	var params signer
 	if err := json.Unmarshal(b, &params); err != nil {
-		fmt.Println("FLAF")
 		return nil, err
 	}
 
-	e := strtobig(params.E)
-	if e == nil {
-		return nil, errors.New("invalid E")
+	alg, err := getAlgorithm(params.Algoritm)
+	if err != nil {
+		return nil, err
 	}
 
-	n := strtobig(params.N)
-	if n == nil {
-		return nil, errors.New("invalid N")
+	pub, err := parsePublicKey(params.E, params.N)
+	if err != nil {
+		return nil, err
 	}
 
-	d := strtobig(params.D)
-	if d == nil {
-		return nil, errors.New("invalid D")
+	d, err := decode("d", params.D)
+	if err != nil {
+		return nil, err
+	}
+	if d.Sign() == 0 || d.Cmp(pub.N) >= 0 {
+		return nil, errors.New("invalid d: not in the range of n")
 	}
 
-	q := strtobig(params.Q)
-	if q == nil {
-		return nil, errors.New("invalid Q")
+	p, err := decode("p", params.P)
+	if err != nil {
+		return nil, err
 	}
 
-	p := strtobig(params.P)
-	if p == nil {
-		return nil, errors.New("invalid P")
+	q, err := decode("q", params.Q)
+	if err != nil {
+		return nil, err
 	}
 
-	dq := strtobig(params.DQ)
-	if dq == nil {
-		return nil, errors.New("invalid DQ")
+	dp, err := decode("dp", params.DP)
+	if err != nil {
+		return nil, err
 	}
 
-	dp := strtobig(params.DP)
-	if dp == nil {
-		return nil, errors.New("invalid DP")
+	dq, err := decode("dq", params.DQ)
+	if err != nil {
+		return nil, err
 	}
 
-	qi := strtobig(params.QI)
-	if qi == nil {
-		return nil, errors.New("invalid QI")
+	qi, err := decode("qi", params.QI)
+	if err != nil {
+		return nil, err
 	}
 
 	key := &rsa.PrivateKey{
-		D:      d,
-		Primes: []*big.Int{p, q},
-		Precomputed: rsa.PrecomputedValues{
-			Dp:        dp,
-			Dq:        dq,
-			Qinv:      qi,
-			CRTValues: []rsa.CRTValue{},
-		},
-		PublicKey: rsa.PublicKey{
-			E: int(e.Int64()),
-			N: n,
-		},
-	}
-
-	err := key.Validate()
-	if err != nil {
+		D:         d,
+		Primes:    []*big.Int{p, q},
+		PublicKey: *pub,
+	}
+
+	// Validate checks p*q == n and that d is the inverse of e, and must be done
+	// before Precompute which divides by the primes
+	if err := key.Validate(); err != nil {
 		return nil, err
 	}
 
-	alg := jwt.GetAlgorithm(params.Algoritm)
+	// The supplied CRT values are not trusted, but must be the derived ones
+	key.Precompute()
+	if key.Precomputed.Dp.Cmp(dp) != 0 || key.Precomputed.Dq.Cmp(dq) != 0 || key.Precomputed.Qinv.Cmp(qi) != 0 {
+		return nil, errors.New("dp, dq and qi do not match the key")
+	}
 
 	return alg.NewSigner(kid, key), nil
 }
@@ -0,0 +1,89 @@
+package jwk
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"github.com/KalleDK/go-jwt/jwt"
+)
+
+var (
+	// ErrPrivateKeyMaterial is returned when a JWK expected to be public carries private key material
+	ErrPrivateKeyMaterial = errors.New("jwk contains private key material")
+	// ErrMissingMember is returned when a JWK lacks a member required by its key type
+	ErrMissingMember = errors.New("jwk is missing a required member")
+)
+
+// thumbprintMembers are the required members of each key type (RFC 7638 3.2)
+var thumbprintMembers = map[string][]string{
+	"EC":  {"crv", "kty", "x", "y"},
+	"RSA": {"e", "kty", "n"},
+	"oct": {"k", "kty"},
+}
+
+// privateMembers are the members holding private key material (RFC 7518 6)
+var privateMembers = []string{"d", "p", "q", "dp", "dq", "qi", "oth", "k"}
+
+// Thumbprint returns the base64url encoded RFC 7638 SHA-256 thumbprint of a JWK
+func Thumbprint(b []byte) (string, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(b, &members); err != nil {
+		return "", err
+	}
+
+	var kty string
+	if err := json.Unmarshal(members["kty"], &kty); err != nil {
+		return "", ErrMissingMember
+	}
+
+	names, ok := thumbprintMembers[kty]
+	if !ok {
+		return "", errors.New("jwk has unknown key type " + kty)
+	}
+
+	required := map[string]string{}
+	for _, name := range names {
+		var v string
+		if err := json.Unmarshal(members[name], &v); err != nil {
+			return "", ErrMissingMember
+		}
+		required[name] = v
+	}
+
+	// Maps are marshaled with sorted keys and without whitespace
+	canonical, err := json.Marshal(required)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(canonical)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// ParsePublicKey parses the public key of a JWK regardless of its key_ops
+func ParsePublicKey(b []byte) (crypto.PublicKey, error) {
+	var header jwkheader
+	if err := json.Unmarshal(b, &header); err != nil {
+		return nil, err
+	}
+
+	keytype := jwt.GetKeyType(header.KeyType)
+
+	return keytype.ParsePublicKey(b)
+}
+
+func hasPrivateMembers(b []byte) (bool, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(b, &members); err != nil {
+		return false, err
+	}
+	for _, name := range privateMembers {
+		if _, ok := members[name]; ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
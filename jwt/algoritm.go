@@ -2,12 +2,23 @@ package jwt
 
 import (
 	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"errors"
 	"io"
 	"strconv"
 
 	"github.com/KalleDK/go-jwt/jwa"
 )
 
+var (
+	// ErrUnsupportedAlgorithm is returned when an algorithm is unknown or not registered
+	ErrUnsupportedAlgorithm = errors.New("unsupported algorithm")
+	// ErrKeyMismatch is returned when a key can not be used with an algorithm
+	ErrKeyMismatch = errors.New("key does not match algorithm")
+)
+
 // Algorithm is the different JWT algoritms
 type Algorithm uint8
 
@@ -33,19 +44,26 @@ type Verifier interface {
 	KeyID() string
 }
 
+// KeyVerifier is a Verifier exposing its public key, e.g. for publishing it
+type KeyVerifier interface {
+	Verifier
+	PublicKey() crypto.PublicKey
+}
+
 type verifier struct {
 	verifier jwa.Verifier
 	alg      Algorithm
 	kid      string
+	key      crypto.PublicKey
 }
 
 func (v verifier) Verify(a Algorithm, kidSuggest string, signed, signature []byte) (kidUsed string, err error) {
 	if a != v.alg {
-		return "", ErrInvalidSignature
+		return "", &UnsupportedAlgorithmError{Algorithm: a.String()}
 	}
 
 	if err := v.verifier.Verify(signed, signature); err != nil {
-		return "", err
+		return "", &SignatureError{KeyID: v.kid, Algorithm: a, Err: err}
 	}
 
 	return v.kid, nil
@@ -59,22 +77,58 @@ func (v verifier) KeyID() string {
 	return v.kid
 }
 
+func (v verifier) PublicKey() crypto.PublicKey {
+	return v.key
+}
+
+func (v verifier) VerifyDigest(a Algorithm, kidSuggest string, digest, signature []byte) (kidUsed string, err error) {
+	if a != v.alg {
+		return "", &UnsupportedAlgorithmError{Algorithm: a.String()}
+	}
+
+	dv, ok := v.verifier.(jwa.DigestVerifier)
+	if !ok {
+		return "", ErrStreamingUnsupported
+	}
+
+	if err := dv.VerifyDigest(digest, signature); err != nil {
+		return "", &SignatureError{KeyID: v.kid, Algorithm: a, Err: err}
+	}
+
+	return v.kid, nil
+}
+
 type Signer interface {
 	Sign(rand io.Reader, unsigned []byte) (signature []byte, err error)
 	Algorithm() Algorithm
 	KeyID() string
 }
 
+// KeySigner is a Signer exposing its private key, e.g. for storing it
+type KeySigner interface {
+	Signer
+	PrivateKey() crypto.PrivateKey
+}
+
 type signer struct {
 	signer jwa.Signer
 	alg    Algorithm
 	kid    string
+	key    crypto.PrivateKey
 }
 
 func (s signer) Sign(rand io.Reader, unsigned []byte) (signature []byte, err error) {
 	return s.signer.Sign(rand, unsigned)
 }
 
+func (s signer) SignDigest(rand io.Reader, digest []byte) (signature []byte, err error) {
+	ds, ok := s.signer.(jwa.DigestSigner)
+	if !ok {
+		return nil, ErrStreamingUnsupported
+	}
+	return ds.SignDigest(rand, digest)
+}
+
 func (s signer) Algorithm() Algorithm {
 	return s.alg
 }
@@ -83,6 +137,10 @@ func (s signer) KeyID() string {
 	return s.kid
 }
 
+func (s signer) PrivateKey() crypto.PrivateKey {
+	return s.key
+}
+
 var algorithms = make([]jwa.Algoritm, maxAlgorithm)
 
 func RegisterAlgorithm(a Algorithm, alg jwa.Algoritm) {
@@ -96,17 +154,55 @@ func (a Algorithm) NewVerifier(kid string, key crypto.PublicKey) verifier {
 	if a > 0 && a < maxAlgorithm {
 		f := algorithms[a]
 		if f != nil {
-			return verifier{f.NewVerifier(key), a, kid}
+			return verifier{f.NewVerifier(key), a, kid, key}
 		}
 	}
 	panic("jwt: requested algorithm #" + strconv.Itoa(int(a)) + " is unavailable")
 }
 
+// Available reports if the algorithm is registered
+func (a Algorithm) Available() bool {
+	return a > 0 && a < maxAlgorithm && algorithms[a] != nil
+}
+
+// NewVerifierForKey is NewVerifier for keys of unknown origin, e.g. taken from
+// a token header. Instead of panicking it returns an error if the algorithm is
+// unavailable or none, or the key does not fit the algorithm
+func NewVerifierForKey(a Algorithm, kid string, key crypto.PublicKey) (Verifier, error) {
+	if !a.Available() || a == None {
+		return nil, &UnsupportedAlgorithmError{Algorithm: a.String()}
+	}
+	if !a.fitsKey(key) {
+		return nil, ErrKeyMismatch
+	}
+	return a.NewVerifier(kid, key), nil
+}
+
+func (a Algorithm) fitsKey(key crypto.PublicKey) bool {
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		switch a {
+		case ES256:
+			return k.Curve == elliptic.P256()
+		case ES384:
+			return k.Curve == elliptic.P384()
+		case ES512:
+			return k.Curve == elliptic.P521()
+		}
+	case *rsa.PublicKey:
+		switch a {
+		case RS256, RS384, RS512:
+			return true
+		}
+	}
+	return false
+}
+
 func (a Algorithm) NewSigner(kid string, key crypto.PrivateKey) signer {
 	if a > 0 && a < maxAlgorithm {
 		f := algorithms[a]
 		if f != nil {
-			return signer{f.NewSigner(key), a, kid}
+			return signer{f.NewSigner(key), a, kid, key}
 		}
 	}
 	panic("jwt: requested algorithm #" + strconv.Itoa(int(a)) + " is unavailable")
@@ -122,6 +218,12 @@ func (a Algorithm) String() string {
 		return "ES256"
 	case ES384:
 		return "ES384"
+	case RS256:
+		return "RS256"
+	case RS384:
+		return "RS384"
+	case RS512:
+		return "RS512"
 	default:
 		return "unknown algorithm value " + strconv.Itoa(int(a))
 	}
@@ -148,6 +250,21 @@ func (a Algorithm) SignatureSize() int {
 	}
 }
 
+// Hash returns the hash function used to compute the digest that is signed,
+// or zero if the algorithm does not hash the signing input
+func (a Algorithm) Hash() crypto.Hash {
+	switch a {
+	case RS256, ES256:
+		return crypto.SHA256
+	case RS384, ES384:
+		return crypto.SHA384
+	case RS512, ES512:
+		return crypto.SHA512
+	default:
+		return 0
+	}
+}
+
 func GetAlgorithm(s string) Algorithm {
 	switch s {
 	case "RS256":
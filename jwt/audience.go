@@ -0,0 +1,118 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidIssuer is matched by errors.Is for an IssuerError
+	ErrInvalidIssuer = errors.New("invalid issuer")
+	// ErrInvalidAudience is matched by errors.Is for an AudienceError
+	ErrInvalidAudience = errors.New("invalid audience")
+)
+
+// Audience is the aud claim, which RFC 7519 4.1.3 allows to be a single
+// string or an array of strings. A single audience is marshaled as a string
+type Audience []string
+
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+func (a *Audience) UnmarshalJSON(b []byte) error {
+	// null leaves the audience unchanged, as encoding/json does for slices
+	if string(b) == "null" {
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*a = Audience{s}
+		return nil
+	}
+
+	var l []string
+	if err := json.Unmarshal(b, &l); err != nil {
+		return err
+	}
+	*a = Audience(l)
+	return nil
+}
+
+// Contains reports if s is one of the audiences
+func (a Audience) Contains(s string) bool {
+	for _, v := range a {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// IssuerError is returned when the iss claim is not the expected issuer
+type IssuerError struct {
+	Expected string
+	Actual   string
+}
+
+func (e *IssuerError) Error() string {
+	return "invalid issuer " + strconv.Quote(e.Actual) + ", expected " + strconv.Quote(e.Expected)
+}
+
+func (e *IssuerError) Is(target error) bool { return target == ErrInvalidIssuer }
+
+// AudienceError is returned when the aud claim lacks the expected audiences
+type AudienceError struct {
+	Expected   []string
+	Actual     Audience
+	RequireAll bool
+}
+
+func (e *AudienceError) Error() string {
+	want := "one of"
+	if e.RequireAll {
+		want = "all of"
+	}
+	return "invalid audience [" + strings.Join(e.Actual, ", ") + "], expected " + want + " [" + strings.Join(e.Expected, ", ") + "]"
+}
+
+func (e *AudienceError) Is(target error) bool { return target == ErrInvalidAudience }
+
+// IssuerValidator requires the iss claim to be Issuer
+type IssuerValidator struct {
+	Issuer string
+}
+
+func (v IssuerValidator) ValidateClaims(claims *RegisteredClaims) error {
+	if claims.Issuer != v.Issuer {
+		return &ClaimError{Claim: "iss", Err: &IssuerError{Expected: v.Issuer, Actual: claims.Issuer}}
+	}
+	return nil
+}
+
+// AudienceValidator requires the aud claim to contain at least one of the
+// Audiences, or all of them if RequireAll is set
+type AudienceValidator struct {
+	Audiences  []string
+	RequireAll bool
+}
+
+func (v AudienceValidator) ValidateClaims(claims *RegisteredClaims) error {
+	matches := 0
+	for _, a := range v.Audiences {
+		if claims.Audience.Contains(a) {
+			matches++
+		}
+	}
+
+	if matches == 0 || (v.RequireAll && matches < len(v.Audiences)) {
+		return &ClaimError{Claim: "aud", Err: &AudienceError{Expected: v.Audiences, Actual: claims.Audience, RequireAll: v.RequireAll}}
+	}
+	return nil
+}
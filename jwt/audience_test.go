@@ -0,0 +1,80 @@
+package jwt_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/KalleDK/go-jwt/jwt"
+)
+
+func TestAudienceJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		want     jwt.Audience
+		wantJSON string
+		wantErr  bool
+	}{
+		{name: "string", json: `{"aud":"api"}`, want: jwt.Audience{"api"}, wantJSON: `{"aud":"api"}`},
+		{name: "array", json: `{"aud":["api","web"]}`, want: jwt.Audience{"api", "web"}, wantJSON: `{"aud":["api","web"]}`},
+		{name: "single element array", json: `{"aud":["api"]}`, want: jwt.Audience{"api"}, wantJSON: `{"aud":"api"}`},
+		{name: "absent", json: `{}`, want: nil, wantJSON: `{}`},
+		{name: "null", json: `{"aud":null}`, want: nil, wantJSON: `{}`},
+		{name: "number", json: `{"aud":1}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var claims jwt.RegisteredClaims
+			err := json.Unmarshal([]byte(tt.json), &claims)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(claims.Audience, tt.want) {
+				t.Errorf("Unmarshal() aud = %#v, want %#v", claims.Audience, tt.want)
+			}
+			b, err := json.Marshal(claims)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(b) != tt.wantJSON {
+				t.Errorf("Marshal() = %s, want %s", b, tt.wantJSON)
+			}
+		})
+	}
+}
+
+func TestIssuerAudienceValidator(t *testing.T) {
+	claims := jwt.RegisteredClaims{Issuer: "https://issuer.example.com", Audience: jwt.Audience{"api", "web"}}
+
+	tests := []struct {
+		name      string
+		validator jwt.ClaimsValidator
+		wantErr   error
+	}{
+		{name: "issuer", validator: jwt.IssuerValidator{Issuer: "https://issuer.example.com"}},
+		{name: "wrong issuer", validator: jwt.IssuerValidator{Issuer: "https://other.example.com"}, wantErr: jwt.ErrInvalidIssuer},
+		{name: "any audience", validator: jwt.AudienceValidator{Audiences: []string{"mobile", "web"}}},
+		{name: "no audience", validator: jwt.AudienceValidator{Audiences: []string{"mobile"}}, wantErr: jwt.ErrInvalidAudience},
+		{name: "all audiences", validator: jwt.AudienceValidator{Audiences: []string{"api", "web"}, RequireAll: true}},
+		{name: "not all audiences", validator: jwt.AudienceValidator{Audiences: []string{"api", "mobile"}, RequireAll: true}, wantErr: jwt.ErrInvalidAudience},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.validator.ValidateClaims(&claims)
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Errorf("ValidateClaims() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	var issuerErr *jwt.IssuerError
+	err := jwt.IssuerValidator{Issuer: "https://other.example.com"}.ValidateClaims(&claims)
+	if !errors.As(err, &issuerErr) || issuerErr.Actual != claims.Issuer {
+		t.Errorf("ValidateClaims() error = %#v, want *IssuerError", err)
+	}
+}
@@ -0,0 +1,86 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrTokenExpired is returned when the exp claim has passed
+	ErrTokenExpired = errors.New("token is expired")
+	// ErrTokenNotValidYet is returned when the nbf claim has not been reached
+	ErrTokenNotValidYet = errors.New("token is not valid yet")
+	// ErrTokenIssuedInFuture is returned when the iat claim is in the future
+	ErrTokenIssuedInFuture = errors.New("token is issued in the future")
+)
+
+// RegisteredClaims are the claims registered by RFC 7519 4.1. Times are nil
+// when the claim is absent
+type RegisteredClaims struct {
+	Issuer    string       `json:"iss,omitempty"`
+	Subject   string       `json:"sub,omitempty"`
+	Audience  Audience     `json:"aud,omitempty"`
+	ExpiresAt *NumericDate `json:"exp,omitempty"`
+	NotBefore *NumericDate `json:"nbf,omitempty"`
+	IssuedAt  *NumericDate `json:"iat,omitempty"`
+	ID        string       `json:"jti,omitempty"`
+}
+
+// ClaimsValidator validates the registered claims of a token once its
+// signature is verified
+type ClaimsValidator interface {
+	ValidateClaims(claims *RegisteredClaims) error
+}
+
+// ClaimsValidatorFunc is a function used as a ClaimsValidator
+type ClaimsValidatorFunc func(claims *RegisteredClaims) error
+
+func (f ClaimsValidatorFunc) ValidateClaims(claims *RegisteredClaims) error {
+	return f(claims)
+}
+
+// TimeValidator validates the exp, nbf and iat claims, allowing the clocks of
+// the issuer and the verifier to differ by Leeway
+type TimeValidator struct {
+	Leeway time.Duration
+	// Now returns the current time, time.Now is used if nil
+	Now func() time.Time
+}
+
+func (v TimeValidator) ValidateClaims(claims *RegisteredClaims) error {
+	now := time.Now
+	if v.Now != nil {
+		now = v.Now
+	}
+	t := now()
+
+	if claims.ExpiresAt != nil && !t.Before(claims.ExpiresAt.Add(v.Leeway)) {
+		return &ClaimError{Claim: "exp", Err: ErrTokenExpired}
+	}
+	if claims.NotBefore != nil && t.Add(v.Leeway).Before(claims.NotBefore.Time) {
+		return &ClaimError{Claim: "nbf", Err: ErrTokenNotValidYet}
+	}
+	if claims.IssuedAt != nil && t.Add(v.Leeway).Before(claims.IssuedAt.Time) {
+		return &ClaimError{Claim: "iat", Err: ErrTokenIssuedInFuture}
+	}
+	return nil
+}
+
+func validateClaims(payloadbuf []byte, validators []ClaimsValidator) error {
+	if len(validators) == 0 {
+		return nil
+	}
+
+	var claims RegisteredClaims
+	if err := json.Unmarshal(payloadbuf, &claims); err != nil {
+		return err
+	}
+
+	for _, v := range validators {
+		if err := v.ValidateClaims(&claims); err != nil {
+			return err
+		}
+	}
+	return nil
+}
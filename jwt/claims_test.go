@@ -0,0 +1,60 @@
+package jwt_test
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/KalleDK/go-jwt/jwt"
+)
+
+func TestTimeValidator(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	clock := func() time.Time { return now }
+
+	tests := []struct {
+		name    string
+		claims  jwt.RegisteredClaims
+		leeway  time.Duration
+		wantErr error
+	}{
+		{name: "no times", claims: jwt.RegisteredClaims{}},
+		{name: "valid", claims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(now.Add(60 * time.Second)), NotBefore: jwt.NewNumericDate(now.Add(-60 * time.Second)), IssuedAt: jwt.NewNumericDate(now.Add(-60 * time.Second))}},
+		{name: "expired", claims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(now)}, wantErr: jwt.ErrTokenExpired},
+		{name: "expired within leeway", claims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(now.Add(-30 * time.Second))}, leeway: time.Minute},
+		{name: "expired beyond leeway", claims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(now.Add(-90 * time.Second))}, leeway: time.Minute, wantErr: jwt.ErrTokenExpired},
+		{name: "not valid yet", claims: jwt.RegisteredClaims{NotBefore: jwt.NewNumericDate(now.Add(1 * time.Second))}, wantErr: jwt.ErrTokenNotValidYet},
+		{name: "not valid yet within leeway", claims: jwt.RegisteredClaims{NotBefore: jwt.NewNumericDate(now.Add(30 * time.Second))}, leeway: time.Minute},
+		{name: "issued in future", claims: jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(now.Add(1 * time.Second))}, wantErr: jwt.ErrTokenIssuedInFuture},
+		{name: "issued in future within leeway", claims: jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(now.Add(30 * time.Second))}, leeway: time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := jwt.TimeValidator{Leeway: tt.leeway, Now: clock}
+			if err := v.ValidateClaims(&tt.claims); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateClaims() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUnmarshalValidators(t *testing.T) {
+	key := newTestKey(t, "key")
+	now := time.Now()
+
+	b, err := jwt.Marshal(rand.Reader, jwt.RegisteredClaims{Subject: "1234567890", ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour))}, key.signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var claims jwt.RegisteredClaims
+	if _, err := jwt.Unmarshal(b, &claims, key.verifier, jwt.TimeValidator{}); err != nil {
+		t.Errorf("Unmarshal() error = %v", err)
+	}
+
+	later := jwt.TimeValidator{Now: func() time.Time { return now.Add(2 * time.Hour) }}
+	if _, err := jwt.Unmarshal(b, &claims, key.verifier, later); !errors.Is(err, jwt.ErrTokenExpired) {
+		t.Errorf("Unmarshal() error = %v, want %v", err, jwt.ErrTokenExpired)
+	}
+}
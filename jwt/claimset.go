@@ -0,0 +1,230 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+)
+
+var (
+	// ErrInvalidClaimType is matched by errors.Is for a ClaimTypeError
+	ErrInvalidClaimType = errors.New("invalid claim type")
+	// ErrRegisteredClaim is returned when Extra holds a registered claim
+	ErrRegisteredClaim = errors.New("registered claim in extra claims")
+)
+
+// registeredClaims are the names of the fields of RegisteredClaims
+var registeredClaims = map[string]bool{
+	"iss": true,
+	"sub": true,
+	"aud": true,
+	"exp": true,
+	"nbf": true,
+	"iat": true,
+	"jti": true,
+}
+
+// Claims are the registered claims and every other claim of a token. Other
+// claims are kept as raw JSON in Extra, so numbers, booleans and objects are
+// marshaled back exactly as they were unmarshaled. Registered claims which are
+// not changed after unmarshaling are marshaled back exactly too
+type Claims struct {
+	RegisteredClaims
+	Extra map[string]json.RawMessage
+
+	// raw are the registered claims as unmarshaled, and decoded their values
+	raw     map[string]json.RawMessage
+	decoded map[string]interface{}
+}
+
+// ClaimTypeError is returned when a claim can not be decoded as the requested type
+type ClaimTypeError struct {
+	Name string
+	Type string
+	Err  error
+}
+
+func (e *ClaimTypeError) Error() string {
+	return "claim " + strconv.Quote(e.Name) + " is not a " + e.Type + ": " + e.Err.Error()
+}
+
+func (e *ClaimTypeError) Is(target error) bool { return target == ErrInvalidClaimType }
+
+func (e *ClaimTypeError) Unwrap() error { return e.Err }
+
+func (c Claims) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(c.RegisteredClaims)
+	if err != nil {
+		return nil, err
+	}
+	if len(c.Extra) == 0 && len(c.raw) == 0 {
+		return b, nil
+	}
+
+	all := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &all); err != nil {
+		return nil, err
+	}
+
+	// Re-encoding is lossy, e.g. a single audience array becomes a string
+	// and 1.50 becomes 1.5, so unchanged claims are kept as they were
+	current := registeredValues(&c.RegisteredClaims)
+	for name, raw := range c.raw {
+		if reflect.DeepEqual(current[name], c.decoded[name]) {
+			all[name] = raw
+		}
+	}
+
+	for name, value := range c.Extra {
+		if registeredClaims[name] {
+			return nil, ErrRegisteredClaim
+		}
+		all[name] = value
+	}
+	return json.Marshal(all)
+}
+
+func (c *Claims) UnmarshalJSON(b []byte) error {
+	var registered RegisteredClaims
+	if err := json.Unmarshal(b, &registered); err != nil {
+		return err
+	}
+
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(b, &all); err != nil {
+		return err
+	}
+	raw := map[string]json.RawMessage{}
+	for name := range registeredClaims {
+		if value, ok := all[name]; ok {
+			raw[name] = value
+			delete(all, name)
+		}
+	}
+
+	c.RegisteredClaims = registered
+	c.Extra = all
+	c.raw = raw
+	c.decoded = registeredValues(&registered)
+	return nil
+}
+
+// registeredValues returns copies of the registered claims by name, for
+// detecting which ones have changed since they were unmarshaled
+func registeredValues(c *RegisteredClaims) map[string]interface{} {
+	date := func(d *NumericDate) interface{} {
+		if d == nil {
+			return nil
+		}
+		return d.Time
+	}
+	return map[string]interface{}{
+		"iss": c.Issuer,
+		"sub": c.Subject,
+		"aud": append(Audience(nil), c.Audience...),
+		"exp": date(c.ExpiresAt),
+		"nbf": date(c.NotBefore),
+		"iat": date(c.IssuedAt),
+		"jti": c.ID,
+	}
+}
+
+// Has reports if the extra claim name is present
+func (c *Claims) Has(name string) bool {
+	_, ok := c.Extra[name]
+	return ok
+}
+
+// Get unmarshals the extra claim name into v. A ClaimError wrapping
+// ErrMissingClaim is returned if it is absent
+func (c *Claims) Get(name string, v interface{}) error {
+	return c.get(name, "value", v)
+}
+
+// Set marshals v as the extra claim name
+func (c *Claims) Set(name string, v interface{}) error {
+	if registeredClaims[name] {
+		return ErrRegisteredClaim
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if c.Extra == nil {
+		c.Extra = map[string]json.RawMessage{}
+	}
+	c.Extra[name] = b
+	return nil
+}
+
+// String returns the extra claim name as a string
+func (c *Claims) String(name string) (string, error) {
+	var s string
+	err := c.get(name, "string", &s)
+	return s, err
+}
+
+// Strings returns the extra claim name as an array of strings
+func (c *Claims) Strings(name string) ([]string, error) {
+	var l []string
+	err := c.get(name, "string array", &l)
+	return l, err
+}
+
+// Bool returns the extra claim name as a boolean
+func (c *Claims) Bool(name string) (bool, error) {
+	var b bool
+	err := c.get(name, "boolean", &b)
+	return b, err
+}
+
+// Int64 returns the extra claim name as an integer
+func (c *Claims) Int64(name string) (int64, error) {
+	var n json.Number
+	if err := c.get(name, "integer", &n); err != nil {
+		return 0, err
+	}
+	i, err := n.Int64()
+	if err != nil {
+		return 0, &ClaimTypeError{Name: name, Type: "integer", Err: err}
+	}
+	return i, nil
+}
+
+// Float64 returns the extra claim name as a number
+func (c *Claims) Float64(name string) (float64, error) {
+	var n json.Number
+	if err := c.get(name, "number", &n); err != nil {
+		return 0, err
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return 0, &ClaimTypeError{Name: name, Type: "number", Err: err}
+	}
+	return f, nil
+}
+
+// Time returns the extra claim name as a NumericDate
+func (c *Claims) Time(name string) (*NumericDate, error) {
+	var d NumericDate
+	if err := c.get(name, "numeric date", &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (c *Claims) get(name string, typ string, v interface{}) error {
+	b, ok := c.Extra[name]
+	if !ok {
+		return &ClaimError{Claim: name, Err: ErrMissingClaim}
+	}
+	// json.Number also accepts numbers quoted as strings
+	if _, number := v.(*json.Number); number && len(b) > 0 && b[0] == '"' {
+		return &ClaimTypeError{Name: name, Type: typ, Err: errors.New("quoted number")}
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return &ClaimTypeError{Name: name, Type: typ, Err: err}
+	}
+	return nil
+}
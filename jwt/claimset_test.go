@@ -0,0 +1,140 @@
+package jwt_test
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/KalleDK/go-jwt/jwt"
+)
+
+func TestClaimsRoundTrip(t *testing.T) {
+	key := newTestKey(t, "key")
+	payload := `{"admin":true,"aud":"api","big":12345678901234567890,"iat":1516239022,"nested":{"a":[1,2.5]},"ratio":0.1,"sub":"1234567890"}`
+
+	b := signCompact(t, key.signer, `{"alg":"ES256","typ":"JWT"}`, payload)
+
+	var claims jwt.Claims
+	if _, err := jwt.Unmarshal(b, &claims, key.verifier); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if claims.Subject != "1234567890" || !claims.Audience.Contains("api") || claims.IssuedAt == nil {
+		t.Errorf("Unmarshal() registered claims = %+v", claims.RegisteredClaims)
+	}
+	if claims.Has("sub") || !claims.Has("admin") {
+		t.Errorf("Unmarshal() extra claims = %v", claims.Extra)
+	}
+
+	token, err := jwt.Marshal(rand.Reader, claims, key.signer)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var again jwt.Claims
+	if _, err := jwt.Unmarshal(token, &again, key.verifier); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	got, err := again.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != payload {
+		t.Errorf("round trip = %s, want %s", got, payload)
+	}
+}
+
+func TestClaimsRoundTripRegistered(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		change  func(c *jwt.Claims)
+		want    string
+	}{
+		{name: "single audience array", payload: `{"aud":["x"]}`, want: `{"aud":["x"]}`},
+		{name: "fractional exp", payload: `{"exp":1.5}`, want: `{"exp":1.5}`},
+		{name: "fractional iat and nbf", payload: `{"iat":1516239022.25,"nbf":1516239022.125,"sub":"a"}`, want: `{"iat":1516239022.25,"nbf":1516239022.125,"sub":"a"}`},
+		{
+			name:    "changed audience",
+			payload: `{"aud":["x"],"exp":1.5}`,
+			change:  func(c *jwt.Claims) { c.Audience = jwt.Audience{"y"} },
+			want:    `{"aud":"y","exp":1.5}`,
+		},
+		{
+			name:    "changed exp",
+			payload: `{"aud":["x"],"exp":1.5}`,
+			change:  func(c *jwt.Claims) { c.ExpiresAt = jwt.NewNumericDate(time.Unix(2, 0)) },
+			want:    `{"aud":["x"],"exp":2}`,
+		},
+		{
+			name:    "removed exp",
+			payload: `{"exp":1.5}`,
+			change:  func(c *jwt.Claims) { c.ExpiresAt = nil },
+			want:    `{}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var claims jwt.Claims
+			if err := json.Unmarshal([]byte(tt.payload), &claims); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if tt.change != nil {
+				tt.change(&claims)
+			}
+			got, err := json.Marshal(claims)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Marshal() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClaimsGetters(t *testing.T) {
+	var claims jwt.Claims
+	if err := claims.UnmarshalJSON([]byte(`{"sub":"x","name":"John","admin":true,"n":42,"f":1.5,"s":"42","roles":["a","b"],"auth_time":1516239022}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	if s, err := claims.String("name"); err != nil || s != "John" {
+		t.Errorf("String() = %v, %v", s, err)
+	}
+	if b, err := claims.Bool("admin"); err != nil || !b {
+		t.Errorf("Bool() = %v, %v", b, err)
+	}
+	if n, err := claims.Int64("n"); err != nil || n != 42 {
+		t.Errorf("Int64() = %v, %v", n, err)
+	}
+	if f, err := claims.Float64("f"); err != nil || f != 1.5 {
+		t.Errorf("Float64() = %v, %v", f, err)
+	}
+	if l, err := claims.Strings("roles"); err != nil || len(l) != 2 {
+		t.Errorf("Strings() = %v, %v", l, err)
+	}
+	if d, err := claims.Time("auth_time"); err != nil || d.Unix() != 1516239022 {
+		t.Errorf("Time() = %v, %v", d, err)
+	}
+
+	tests := []struct {
+		name    string
+		get     func() error
+		wantErr error
+	}{
+		{name: "missing", get: func() error { _, err := claims.String("missing"); return err }, wantErr: jwt.ErrMissingClaim},
+		{name: "registered", get: func() error { _, err := claims.String("sub"); return err }, wantErr: jwt.ErrMissingClaim},
+		{name: "string as bool", get: func() error { _, err := claims.Bool("name"); return err }, wantErr: jwt.ErrInvalidClaimType},
+		{name: "float as integer", get: func() error { _, err := claims.Int64("f"); return err }, wantErr: jwt.ErrInvalidClaimType},
+		{name: "quoted number", get: func() error { _, err := claims.Int64("s"); return err }, wantErr: jwt.ErrInvalidClaimType},
+		{name: "set registered", get: func() error { return claims.Set("exp", 1) }, wantErr: jwt.ErrRegisteredClaim},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.get(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
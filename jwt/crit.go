@@ -0,0 +1,82 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+var (
+	// ErrMalformedCritical is returned when the crit header is not a non-empty
+	// list of extension parameters present in the header
+	ErrMalformedCritical = errors.New("malformed crit header")
+	// ErrUnknownCritical is returned when the crit header lists an extension
+	// parameter that is not understood
+	ErrUnknownCritical = errors.New("unknown critical header")
+)
+
+// CriticalHeader is implemented by headers that process extension parameters
+// themselves. Understands reports if the named parameter may be listed in the
+// crit header, in addition to those registered with RegisterCriticalHeader
+type CriticalHeader interface {
+	Understands(name string) bool
+}
+
+// registeredHeaders are the parameters defined by RFC 7515 which must never
+// be listed as critical
+var registeredHeaders = map[string]bool{
+	"alg":      true,
+	"jku":      true,
+	"jwk":      true,
+	"kid":      true,
+	"x5u":      true,
+	"x5c":      true,
+	"x5t":      true,
+	"x5t#S256": true,
+	"typ":      true,
+	"cty":      true,
+	"crit":     true,
+}
+
+var criticalHeaders = map[string]bool{}
+
+// RegisterCriticalHeader marks the extension parameter as understood by all
+// headers, so tokens listing it in crit are accepted. It is not safe for
+// concurrent use and should be called from init
+func RegisterCriticalHeader(name string) {
+	if name == "" || registeredHeaders[name] {
+		panic("jwt: RegisterCriticalHeader of registered header " + name)
+	}
+	criticalHeaders[name] = true
+}
+
+// checkCritical validates the crit header of the decoded header (RFC 7515 4.1.11)
+func checkCritical(headerbuf []byte, header Header) error {
+	var params map[string]json.RawMessage
+	if err := json.Unmarshal(headerbuf, &params); err != nil {
+		return err
+	}
+
+	raw, ok := params["crit"]
+	if !ok {
+		return nil
+	}
+
+	var crit []string
+	if err := json.Unmarshal(raw, &crit); err != nil || len(crit) == 0 {
+		return ErrMalformedCritical
+	}
+
+	ch, _ := header.(CriticalHeader)
+	for _, name := range crit {
+		if registeredHeaders[name] {
+			return ErrMalformedCritical
+		}
+		if _, ok := params[name]; !ok {
+			return ErrMalformedCritical
+		}
+		if !criticalHeaders[name] && (ch == nil || !ch.Understands(name)) {
+			return ErrUnknownCritical
+		}
+	}
+	return nil
+}
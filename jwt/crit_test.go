@@ -0,0 +1,71 @@
+package jwt_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/KalleDK/go-jwt/jwt"
+)
+
+func init() {
+	jwt.RegisterCriticalHeader("https://example.com/understood")
+}
+
+func TestCriticalHeader(t *testing.T) {
+	key := newTestKey(t, "key")
+
+	tests := []struct {
+		name    string
+		header  string
+		wantErr error
+	}{
+		{
+			name:   "no crit",
+			header: `{"typ":"JWT","alg":"ES256","kid":"key"}`,
+		},
+		{
+			name:   "registered extension",
+			header: `{"typ":"JWT","alg":"ES256","kid":"key","crit":["https://example.com/understood"],"https://example.com/understood":true}`,
+		},
+		{
+			name:    "unknown extension",
+			header:  `{"typ":"JWT","alg":"ES256","kid":"key","crit":["exp"],"exp":1363284000}`,
+			wantErr: jwt.ErrUnknownCritical,
+		},
+		{
+			name:    "unencoded payload",
+			header:  `{"typ":"JWT","alg":"ES256","kid":"key","crit":["b64"],"b64":false}`,
+			wantErr: jwt.ErrUnknownCritical,
+		},
+		{
+			name:    "empty list",
+			header:  `{"typ":"JWT","alg":"ES256","kid":"key","crit":[]}`,
+			wantErr: jwt.ErrMalformedCritical,
+		},
+		{
+			name:    "not a list",
+			header:  `{"typ":"JWT","alg":"ES256","kid":"key","crit":"exp"}`,
+			wantErr: jwt.ErrMalformedCritical,
+		},
+		{
+			name:    "registered header",
+			header:  `{"typ":"JWT","alg":"ES256","kid":"key","crit":["alg"]}`,
+			wantErr: jwt.ErrMalformedCritical,
+		},
+		{
+			name:    "missing extension",
+			header:  `{"typ":"JWT","alg":"ES256","kid":"key","crit":["https://example.com/understood"]}`,
+			wantErr: jwt.ErrMalformedCritical,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := signCompact(t, key.signer, tt.header, `{"sub":"1234567890"}`)
+			var got testPayload
+			_, err := jwt.Unmarshal(b, &got, key.verifier)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package jwt
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrPayloadNotDetached is returned when a detached token carries a payload
+var ErrPayloadNotDetached = errors.New("payload is not detached")
+
+// MarshalDetached signs the payload bytes and returns a JWS with a detached
+// payload (RFC 7515 Appendix F) in the form header..signature
+func MarshalDetached(rand io.Reader, payload []byte, signer Signer) ([]byte, error) {
+	header := header{
+		Type: "JWT",
+	}
+	return MarshalDetachedWithHeader(rand, payload, &header, signer)
+}
+
+func MarshalDetachedWithHeader(rand io.Reader, payload []byte, header Header, signer Signer) ([]byte, error) {
+	token, err := signToken(rand, payload, header, signer)
+	if err != nil {
+		return nil, err
+	}
+	return token.detached(), nil
+}
+
+// UnmarshalDetached verifies a JWS with a detached payload against the
+// payload bytes transported out-of-band
+func UnmarshalDetached(b []byte, payload []byte, verifiers Verifiers) (string, error) {
+	var header header
+	return UnmarshalDetachedWithHeader(b, payload, &header, verifiers)
+}
+
+func UnmarshalDetachedWithHeader(b []byte, payload []byte, header Header, verifiers Verifiers) (string, error) {
+	token, err := parseTokenBuffer(b)
+	if err != nil {
+		return "", err
+	}
+
+	if len(token.payloadSlice) != 0 {
+		return "", ErrPayloadNotDetached
+	}
+
+	if err := unmarshalHeader(token.headerSlice, header); err != nil {
+		return "", err
+	}
+
+	token = token.attach(encodedSegmentLength(len(payload)))
+	encodeSegment(token.payloadSlice, payload)
+
+	return unmarshalSignature(header, verifiers, token.signedSlice, token.signatureSlice)
+}
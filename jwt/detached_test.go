@@ -0,0 +1,46 @@
+package jwt_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/KalleDK/go-jwt/jwt"
+)
+
+func TestMarshalDetached(t *testing.T) {
+	key := newTestKey(t, "webhook")
+	body := []byte(`{"event":"order.created","id":42}`)
+
+	b, err := jwt.MarshalDetached(rand.Reader, body, key.signer)
+	if err != nil {
+		t.Fatalf("MarshalDetached() error = %v", err)
+	}
+	if !bytes.Contains(b, []byte("..")) {
+		t.Fatalf("MarshalDetached() = %s, want detached payload", b)
+	}
+
+	tests := []struct {
+		name    string
+		token   []byte
+		payload []byte
+		wantErr bool
+	}{
+		{name: "valid", token: b, payload: body},
+		{name: "tampered payload", token: b, payload: []byte(`{"event":"order.created","id":43}`), wantErr: true},
+		{name: "missing payload", token: b, payload: nil, wantErr: true},
+		{name: "attached payload", token: bytes.Replace(b, []byte(".."), []byte(".e30."), 1), payload: body, wantErr: true},
+		{name: "no dots", token: []byte("abc"), payload: body, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kid, err := jwt.UnmarshalDetached(tt.token, tt.payload, key.verifier)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnmarshalDetached() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && kid != "webhook" {
+				t.Errorf("UnmarshalDetached() kid = %v, want webhook", kid)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package jwt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+// ErrInvalidClaims is matched by errors.Is for a ClaimError
+var ErrInvalidClaims = errors.New("invalid claims")
+
+var (
+	errMissingDot    = errors.New("missing segment separator")
+	errUnexpectedDot = errors.New("unexpected segment separator")
+)
+
+// MalformedError is returned when a token can not be decoded, or its header is
+// invalid. It matches ErrMalformedToken with errors.Is, and wraps the base64
+// or json error, or the error of the header check, e.g. ErrUnknownCritical
+type MalformedError struct {
+	// Segment is "header", "payload" or "signature", or empty if the token
+	// does not consist of three segments
+	Segment string
+	// Offset is the position of the error in the base64 encoded segment for
+	// base64 errors, in the decoded segment for json errors, and in the token
+	// if Segment is empty. It is zero for header checks
+	Offset int
+	Err    error
+}
+
+func (e *MalformedError) Error() string {
+	where := "token"
+	if e.Segment != "" {
+		where = e.Segment
+	}
+	return "malformed " + where + " at offset " + strconv.Itoa(e.Offset) + ": " + e.Err.Error()
+}
+
+func (e *MalformedError) Is(target error) bool { return target == ErrMalformedToken }
+
+func (e *MalformedError) Unwrap() error { return e.Err }
+
+// malformed wraps a base64 or json error of the named segment
+func malformed(segment string, err error) error {
+	var offset int
+	switch e := err.(type) {
+	case *MalformedError:
+		return err
+	case base64.CorruptInputError:
+		offset = int(e)
+	case *json.SyntaxError:
+		offset = int(e.Offset)
+	case *json.UnmarshalTypeError:
+		offset = int(e.Offset)
+	}
+	return &MalformedError{Segment: segment, Offset: offset, Err: err}
+}
+
+// UnsupportedAlgorithmError is returned when the algorithm of a token is
+// unknown, unavailable or not allowed. It matches ErrUnsupportedAlgorithm with
+// errors.Is
+type UnsupportedAlgorithmError struct {
+	Algorithm string
+}
+
+func (e *UnsupportedAlgorithmError) Error() string {
+	return "unsupported algorithm " + strconv.Quote(e.Algorithm)
+}
+
+func (e *UnsupportedAlgorithmError) Is(target error) bool { return target == ErrUnsupportedAlgorithm }
+
+// KeyNotFoundError is returned when no verifier has the key ID of a token. It
+// matches ErrNoVerifiersWithKID with errors.Is
+type KeyNotFoundError struct {
+	KeyID string
+}
+
+func (e *KeyNotFoundError) Error() string {
+	return "no verifiers with kid " + strconv.Quote(e.KeyID)
+}
+
+func (e *KeyNotFoundError) Is(target error) bool { return target == ErrNoVerifiersWithKID }
+
+// SignatureError is returned when the signature of a token is invalid. It
+// matches ErrInvalidSignature with errors.Is, and wraps the error of the
+// algorithm if a single key was tried
+type SignatureError struct {
+	// KeyID is the kid of the key tried, or of the token if several were tried
+	KeyID     string
+	Algorithm Algorithm
+	Err       error
+}
+
+func (e *SignatureError) Error() string {
+	s := "signature is invalid"
+	if e.KeyID != "" {
+		s += " for kid " + strconv.Quote(e.KeyID)
+	}
+	if e.Err != nil {
+		s += ": " + e.Err.Error()
+	}
+	return s
+}
+
+func (e *SignatureError) Is(target error) bool { return target == ErrInvalidSignature }
+
+func (e *SignatureError) Unwrap() error { return e.Err }
+
+// ClaimError is returned when a claim is missing or invalid. It matches
+// ErrInvalidClaims with errors.Is, and wraps the cause, e.g. ErrTokenExpired
+// or an *IssuerError
+type ClaimError struct {
+	Claim string
+	Err   error
+}
+
+func (e *ClaimError) Error() string {
+	return "invalid claim " + strconv.Quote(e.Claim) + ": " + e.Err.Error()
+}
+
+func (e *ClaimError) Is(target error) bool { return target == ErrInvalidClaims }
+
+func (e *ClaimError) Unwrap() error { return e.Err }
@@ -0,0 +1,189 @@
+package jwt_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KalleDK/go-jwt/jwt"
+)
+
+func TestErrors(t *testing.T) {
+	key := newTestKey(t, "key")
+	other := newTestKey(t, "other")
+	now := time.Now()
+
+	valid, err := jwt.Marshal(rand.Reader, jwt.RegisteredClaims{Issuer: "issuer"}, key.signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, err := jwt.Marshal(rand.Reader, jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(now.Add(-time.Hour))}, key.signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"ES256","typ":"JWT"}`))
+
+	t.Run("malformed token", func(t *testing.T) {
+		_, err := jwt.Parse([]byte("a.b.c.d"), key.verifier)
+		var merr *jwt.MalformedError
+		if !errors.Is(err, jwt.ErrMalformedToken) || !errors.As(err, &merr) || merr.Segment != "" || merr.Offset != 5 {
+			t.Errorf("Parse() error = %#v", err)
+		}
+	})
+
+	t.Run("malformed base64", func(t *testing.T) {
+		_, err := jwt.Parse([]byte(header+".a!b.AAAA"), key.verifier)
+		var merr *jwt.MalformedError
+		var cerr base64.CorruptInputError
+		if !errors.As(err, &merr) || merr.Segment != "payload" || merr.Offset != 1 || !errors.As(err, &cerr) {
+			t.Errorf("Parse() error = %#v", err)
+		}
+	})
+
+	t.Run("malformed json", func(t *testing.T) {
+		token := signCompact(t, key.signer, `{"alg":"ES256","typ":"JWT"}`, `{"sub":`)
+		_, err := jwt.Parse(token, key.verifier)
+		var merr *jwt.MalformedError
+		var serr *json.SyntaxError
+		if !errors.As(err, &merr) || merr.Segment != "payload" || !errors.As(err, &serr) {
+			t.Errorf("Parse() error = %#v", err)
+		}
+	})
+
+	t.Run("unsupported algorithm", func(t *testing.T) {
+		_, err := jwt.Parse(valid, key.verifier, jwt.WithAlgorithms(jwt.RS256))
+		var aerr *jwt.UnsupportedAlgorithmError
+		if !errors.Is(err, jwt.ErrUnsupportedAlgorithm) || !errors.As(err, &aerr) || aerr.Algorithm != "ES256" {
+			t.Errorf("Parse() error = %#v", err)
+		}
+	})
+
+	t.Run("no verifier for algorithm", func(t *testing.T) {
+		p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		es384 := jwt.ES384.NewVerifier("key", &p384.PublicKey)
+
+		for name, verifiers := range map[string]jwt.Verifiers{
+			"verifier":         es384,
+			"verifiers":        jwt.NewVerifiers(false, es384),
+			"kid must match":   jwt.NewVerifiers(true, es384),
+			"other algorithms": jwt.NewVerifiers(false, es384, jwt.ES384.NewVerifier("other", &p384.PublicKey)),
+		} {
+			_, err := jwt.Parse(valid, verifiers)
+			var aerr *jwt.UnsupportedAlgorithmError
+			if !errors.Is(err, jwt.ErrUnsupportedAlgorithm) || !errors.As(err, &aerr) || aerr.Algorithm != "ES256" {
+				t.Errorf("%s: Parse() error = %#v", name, err)
+			}
+			var serr *jwt.SignatureError
+			if errors.As(err, &serr) {
+				t.Errorf("%s: Parse() error = %#v, want no SignatureError", name, err)
+			}
+		}
+	})
+
+	t.Run("unknown algorithm", func(t *testing.T) {
+		hs256 := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","kid":"key","typ":"JWT"}`)) + ".e30.AAAA"
+		resolver := jwt.ResolverFunc(func(header *jwt.JOSEHeader) (jwt.Verifiers, error) {
+			return key.verifier, nil
+		})
+
+		for name, unmarshal := range map[string]func() error{
+			"Parse": func() error {
+				_, err := jwt.Parse([]byte(hs256), key.verifier)
+				return err
+			},
+			"Unmarshal": func() error {
+				_, err := jwt.Unmarshal([]byte(hs256), &testPayload{}, jwt.NewVerifiers(true, key.verifier))
+				return err
+			},
+			"UnmarshalResolved": func() error {
+				_, err := jwt.UnmarshalResolved([]byte(hs256), &testPayload{}, &jwt.JOSEHeader{}, resolver)
+				return err
+			},
+			"UnmarshalStream": func() error {
+				_, err := jwt.UnmarshalStream(strings.NewReader(hs256), ioutil.Discard, key.verifier)
+				return err
+			},
+		} {
+			err := unmarshal()
+			var aerr *jwt.UnsupportedAlgorithmError
+			if !errors.Is(err, jwt.ErrUnsupportedAlgorithm) || !errors.As(err, &aerr) || aerr.Algorithm != "HS256" {
+				t.Errorf("%s() error = %#v, want HS256 unsupported", name, err)
+			}
+		}
+	})
+
+	t.Run("malformed header", func(t *testing.T) {
+		for _, tt := range []struct {
+			header string
+			want   error
+		}{
+			{header: `{"alg":"ES256","typ":"JWT","crit":["exp"]}`, want: jwt.ErrMalformedCritical},
+			{header: `{"alg":"ES256","typ":"JWT","crit":["tenant"],"tenant":"acme"}`, want: jwt.ErrUnknownCritical},
+		} {
+			token := signCompact(t, key.signer, tt.header, `{}`)
+			_, err := jwt.Parse(token, key.verifier)
+			var merr *jwt.MalformedError
+			if !errors.Is(err, jwt.ErrMalformedToken) || !errors.As(err, &merr) || merr.Segment != "header" || !errors.Is(err, tt.want) {
+				t.Errorf("Parse(%s) error = %#v", tt.header, err)
+			}
+		}
+	})
+
+	t.Run("invalid header type", func(t *testing.T) {
+		typed := signCompact(t, key.signer, `{"alg":"ES256","typ":"at+jwt"}`, `{}`)
+		_, perr := jwt.Parse(typed, key.verifier, jwt.WithTypes("JWT"))
+		_, uerr := jwt.Unmarshal(typed, &testPayload{}, key.verifier)
+		for name, err := range map[string]error{"Parse": perr, "Unmarshal": uerr} {
+			var merr *jwt.MalformedError
+			if !errors.Is(err, jwt.ErrInvalidHeaderType) || !errors.As(err, &merr) || merr.Segment != "header" {
+				t.Errorf("%s() error = %#v", name, err)
+			}
+		}
+	})
+
+	t.Run("key not found", func(t *testing.T) {
+		_, err := jwt.Parse(valid, jwt.NewVerifiers(true, other.verifier))
+		var kerr *jwt.KeyNotFoundError
+		if !errors.Is(err, jwt.ErrNoVerifiersWithKID) || !errors.As(err, &kerr) || kerr.KeyID != "key" {
+			t.Errorf("Parse() error = %#v", err)
+		}
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		forged := signCompact(t, other.signer, `{"alg":"ES256","kid":"key","typ":"JWT"}`, `{}`)
+		_, err := jwt.Parse(forged, key.verifier)
+		var serr *jwt.SignatureError
+		if !errors.Is(err, jwt.ErrInvalidSignature) || !errors.As(err, &serr) || serr.KeyID != "key" || serr.Err == nil {
+			t.Errorf("Parse() error = %#v", err)
+		}
+	})
+
+	t.Run("claim", func(t *testing.T) {
+		_, err := jwt.Parse(expired, key.verifier)
+		var cerr *jwt.ClaimError
+		if !errors.Is(err, jwt.ErrInvalidClaims) || !errors.Is(err, jwt.ErrTokenExpired) || !errors.As(err, &cerr) || cerr.Claim != "exp" {
+			t.Errorf("Parse() error = %#v", err)
+		}
+
+		_, err = jwt.Parse(valid, key.verifier, jwt.WithIssuer("other"))
+		var ierr *jwt.IssuerError
+		if !errors.As(err, &cerr) || cerr.Claim != "iss" || !errors.As(err, &ierr) || ierr.Actual != "issuer" {
+			t.Errorf("Parse() error = %#v", err)
+		}
+
+		_, err = jwt.Parse(valid, key.verifier, jwt.WithRequiredClaims("sub"))
+		if !errors.As(err, &cerr) || cerr.Claim != "sub" || !errors.Is(err, jwt.ErrMissingClaim) {
+			t.Errorf("Parse() error = %#v", err)
+		}
+	})
+}
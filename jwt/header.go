@@ -1,6 +1,10 @@
 package jwt
 
-import "errors"
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
 
 var (
 	// ErrInvalidHeaderType is returned when the type of the header is wrong
@@ -30,11 +34,210 @@ func (h header) Alg() Algorithm { return GetAlgorithm(h.Algorithm) }
 
 func (h *header) SetAlg(a Algorithm) { h.Algorithm = a.String() }
 
+func (h header) algName() string { return h.Algorithm }
+
 func (h header) Typ() string { return h.Type }
 
 func (h header) Valid() error {
-	if h.Typ() != "JWT" {
+	if !MatchType(h.Typ(), "JWT") {
+		return ErrInvalidHeaderType
+	}
+	return nil
+}
+
+// checkAlgorithm returns an UnsupportedAlgorithmError naming the alg header as
+// written in the token, if it is not a known algorithm
+func checkAlgorithm(h Header) error {
+	if h.Alg() != 0 {
+		return nil
+	}
+	name := h.Alg().String()
+	if n, ok := h.(interface{ algName() string }); ok {
+		name = n.algName()
+	}
+	return &UnsupportedAlgorithmError{Algorithm: name}
+}
+
+// MatchType reports if typ is one of the expected media types. Media types
+// are compared case-insensitively and "application/" is implied for values
+// without a "/" (RFC 7515 4.1.9). An empty expected type matches an absent typ
+func MatchType(typ string, expected ...string) bool {
+	typ = normalizeType(typ)
+	for _, e := range expected {
+		if typ == normalizeType(e) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeType(typ string) string {
+	typ = strings.ToLower(typ)
+	if typ != "" && !strings.Contains(typ, "/") {
+		typ = "application/" + typ
+	}
+	return typ
+}
+
+// typedHeader is a JOSEHeader that must have one of the expected types
+type typedHeader struct {
+	JOSEHeader
+	types []string
+}
+
+func (h typedHeader) Valid() error {
+	if !MatchType(h.Type, h.types...) {
 		return ErrInvalidHeaderType
 	}
 	return nil
 }
+
+var (
+	// ErrMissingHeaderParameter is returned when a private header parameter is absent
+	ErrMissingHeaderParameter = errors.New("missing header parameter")
+	// ErrRegisteredHeaderParameter is returned when a private header parameter
+	// uses the name of a registered one
+	ErrRegisteredHeaderParameter = errors.New("registered header parameter")
+)
+
+// JOSEHeader is a header with every parameter registered by RFC 7515. All
+// other parameters are kept as private parameters and survive a round trip
+// through Marshal and Unmarshal. JOSEHeader performs no checks in Valid, so
+// the type must be checked by the caller
+type JOSEHeader struct {
+	Type               string
+	ContentType        string
+	Algorithm          string
+	KeyID              string
+	JWKSetURL          string
+	JWK                json.RawMessage
+	X509URL            string
+	X509CertChain      []string
+	X509Thumbprint     string
+	X509ThumbprintS256 string
+	Critical           []string
+	Private            map[string]json.RawMessage
+}
+
+type joseHeader struct {
+	Type               string          `json:"typ,omitempty"`
+	ContentType        string          `json:"cty,omitempty"`
+	Algorithm          string          `json:"alg,omitempty"`
+	KeyID              string          `json:"kid,omitempty"`
+	JWKSetURL          string          `json:"jku,omitempty"`
+	JWK                json.RawMessage `json:"jwk,omitempty"`
+	X509URL            string          `json:"x5u,omitempty"`
+	X509CertChain      []string        `json:"x5c,omitempty"`
+	X509Thumbprint     string          `json:"x5t,omitempty"`
+	X509ThumbprintS256 string          `json:"x5t#S256,omitempty"`
+	Critical           []string        `json:"crit,omitempty"`
+}
+
+func (h JOSEHeader) Kid() string { return h.KeyID }
+
+func (h *JOSEHeader) SetKid(s string) { h.KeyID = s }
+
+func (h JOSEHeader) Alg() Algorithm { return GetAlgorithm(h.Algorithm) }
+
+func (h *JOSEHeader) SetAlg(a Algorithm) { h.Algorithm = a.String() }
+
+func (h JOSEHeader) algName() string { return h.Algorithm }
+
+func (h JOSEHeader) Typ() string { return h.Type }
+
+func (h JOSEHeader) Cty() string { return h.ContentType }
+
+func (h JOSEHeader) Valid() error { return nil }
+
+// PrivateParam unmarshals the private header parameter name into v
+func (h JOSEHeader) PrivateParam(name string, v interface{}) error {
+	raw, ok := h.Private[name]
+	if !ok {
+		return ErrMissingHeaderParameter
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// SetPrivateParam sets the private header parameter name to the JSON
+// encoding of v
+func (h *JOSEHeader) SetPrivateParam(name string, v interface{}) error {
+	if registeredHeaders[name] {
+		return ErrRegisteredHeaderParameter
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if h.Private == nil {
+		h.Private = map[string]json.RawMessage{}
+	}
+	h.Private[name] = raw
+	return nil
+}
+
+func (h JOSEHeader) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(joseHeader{
+		Type:               h.Type,
+		ContentType:        h.ContentType,
+		Algorithm:          h.Algorithm,
+		KeyID:              h.KeyID,
+		JWKSetURL:          h.JWKSetURL,
+		JWK:                h.JWK,
+		X509URL:            h.X509URL,
+		X509CertChain:      h.X509CertChain,
+		X509Thumbprint:     h.X509Thumbprint,
+		X509ThumbprintS256: h.X509ThumbprintS256,
+		Critical:           h.Critical,
+	})
+	if err != nil || len(h.Private) == 0 {
+		return b, err
+	}
+
+	params := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &params); err != nil {
+		return nil, err
+	}
+	for name, v := range h.Private {
+		if registeredHeaders[name] {
+			return nil, ErrRegisteredHeaderParameter
+		}
+		params[name] = v
+	}
+	return json.Marshal(params)
+}
+
+func (h *JOSEHeader) UnmarshalJSON(b []byte) error {
+	var registered joseHeader
+	if err := json.Unmarshal(b, &registered); err != nil {
+		return err
+	}
+
+	var params map[string]json.RawMessage
+	if err := json.Unmarshal(b, &params); err != nil {
+		return err
+	}
+	for name := range params {
+		if registeredHeaders[name] {
+			delete(params, name)
+		}
+	}
+	if len(params) == 0 {
+		params = nil
+	}
+
+	*h = JOSEHeader{
+		Type:               registered.Type,
+		ContentType:        registered.ContentType,
+		Algorithm:          registered.Algorithm,
+		KeyID:              registered.KeyID,
+		JWKSetURL:          registered.JWKSetURL,
+		JWK:                registered.JWK,
+		X509URL:            registered.X509URL,
+		X509CertChain:      registered.X509CertChain,
+		X509Thumbprint:     registered.X509Thumbprint,
+		X509ThumbprintS256: registered.X509ThumbprintS256,
+		Critical:           registered.Critical,
+		Private:            params,
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package jwt_test
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/KalleDK/go-jwt/jwt"
+)
+
+func TestJOSEHeader(t *testing.T) {
+	key := newTestKey(t, "key")
+
+	header := jwt.JOSEHeader{
+		Type:               "JWT",
+		ContentType:        "example+json",
+		JWKSetURL:          "https://example.com/jwks.json",
+		X509URL:            "https://example.com/chain.pem",
+		X509CertChain:      []string{"MIIB"},
+		X509Thumbprint:     "dGh1bWI",
+		X509ThumbprintS256: "dGh1bWIyNTY",
+		JWK:                json.RawMessage(`{"kty":"EC"}`),
+	}
+	if err := header.SetPrivateParam("tenant", "acme"); err != nil {
+		t.Fatal(err)
+	}
+	if err := header.SetPrivateParam("alg", "none"); err != jwt.ErrRegisteredHeaderParameter {
+		t.Errorf("SetPrivateParam() error = %v, want %v", err, jwt.ErrRegisteredHeaderParameter)
+	}
+
+	b, err := jwt.MarshalWithHeader(rand.Reader, testPayload{Subject: "1234567890"}, &header, key.signer)
+	if err != nil {
+		t.Fatalf("MarshalWithHeader() error = %v", err)
+	}
+
+	var got jwt.JOSEHeader
+	var payload testPayload
+	if _, err := jwt.UnmarshalWithHeader(b, &payload, &got, key.verifier); err != nil {
+		t.Fatalf("UnmarshalWithHeader() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(got, header) {
+		t.Errorf("UnmarshalWithHeader() header = %+v, want %+v", got, header)
+	}
+	if got.Alg() != jwt.ES256 || got.Kid() != "key" || got.Cty() != "example+json" {
+		t.Errorf("UnmarshalWithHeader() alg = %v, kid = %v, cty = %v", got.Alg(), got.Kid(), got.Cty())
+	}
+
+	var tenant string
+	if err := got.PrivateParam("tenant", &tenant); err != nil || tenant != "acme" {
+		t.Errorf("PrivateParam() = %v, %v, want acme", tenant, err)
+	}
+	if err := got.PrivateParam("missing", &tenant); err != jwt.ErrMissingHeaderParameter {
+		t.Errorf("PrivateParam() error = %v, want %v", err, jwt.ErrMissingHeaderParameter)
+	}
+}
+
+func TestJOSEHeaderJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+	}{
+		{name: "registered", json: `{"alg":"ES256","crit":["exp"],"exp":1363284000,"kid":"key","typ":"JWT"}`},
+		{name: "private", json: `{"alg":"ES256","nested":{"a":[1,2.5,true]},"typ":"dpop+jwt"}`},
+		{name: "empty", json: `{}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var h jwt.JOSEHeader
+			if err := json.Unmarshal([]byte(tt.json), &h); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			b, err := json.Marshal(h)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(b) != tt.json {
+				t.Errorf("Marshal() = %s, want %s", b, tt.json)
+			}
+		})
+	}
+}
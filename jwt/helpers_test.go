@@ -0,0 +1,48 @@
+package jwt_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+
+	_ "crypto/sha256"
+
+	_ "github.com/KalleDK/go-jwt/jwa/ecdsa"
+	_ "github.com/KalleDK/go-jwt/jwa/rsa"
+	"github.com/KalleDK/go-jwt/jwt"
+)
+
+type testKey struct {
+	signer   jwt.Signer
+	verifier jwt.Verifier
+}
+
+func newTestKey(t *testing.T, kid string) testKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testKey{
+		signer:   jwt.ES256.NewSigner(kid, key),
+		verifier: jwt.ES256.NewVerifier(kid, &key.PublicKey),
+	}
+}
+
+type testPayload struct {
+	Subject string `json:"sub"`
+	Name    string `json:"name"`
+}
+
+// signCompact builds a compact token from raw header and payload JSON
+func signCompact(t *testing.T, signer jwt.Signer, header, payload string) []byte {
+	t.Helper()
+	signed := base64.RawURLEncoding.EncodeToString([]byte(header)) + "." + base64.RawURLEncoding.EncodeToString([]byte(payload))
+	signature, err := signer.Sign(rand.Reader, []byte(signed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []byte(signed + "." + base64.RawURLEncoding.EncodeToString(signature))
+}
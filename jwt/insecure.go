@@ -0,0 +1,55 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ErrTokenTooLarge is returned when a token exceeds the size limit
+var ErrTokenTooLarge = errors.New("token is too large")
+
+// MaxInsecureTokenSize is the largest token InsecureDecode accepts
+const MaxInsecureTokenSize = 64 << 10
+
+// InsecureDecode decodes the header, claims and signature of a token WITHOUT
+// verifying it, e.g. to select the verifiers by the iss claim or kid header,
+// or for debugging. Nothing in the returned Token may be trusted, and Verified
+// is always false. The crit header is not checked. A token that is not
+// malformed is returned even with an error, e.g. if its payload is not a JSON
+// object
+func InsecureDecode(b []byte) (*Token, error) {
+	return InsecureDecodeLimit(b, MaxInsecureTokenSize)
+}
+
+// InsecureDecodeLimit is InsecureDecode for tokens of at most limit bytes
+func InsecureDecodeLimit(b []byte, limit int) (*Token, error) {
+	if len(b) > limit {
+		return nil, ErrTokenTooLarge
+	}
+
+	token, err := newToken(b)
+	if err != nil {
+		return nil, err
+	}
+
+	headerbuf, err := decodeSegment(token.RawHeader)
+	if err != nil {
+		return nil, malformed("header", err)
+	}
+	if err := json.Unmarshal(headerbuf, &token.Header); err != nil {
+		return nil, malformed("header", err)
+	}
+	token.Algorithm = token.Header.Alg()
+
+	if token.Signature, err = decodeSegment(token.RawSignature); err != nil {
+		return nil, malformed("signature", err)
+	}
+	if token.Payload, err = decodeSegment(token.RawPayload); err != nil {
+		return nil, malformed("payload", err)
+	}
+
+	if err := json.Unmarshal(token.Payload, &token.Claims); err != nil {
+		return token, malformed("payload", err)
+	}
+	return token, nil
+}
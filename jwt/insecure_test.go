@@ -0,0 +1,53 @@
+package jwt_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/KalleDK/go-jwt/jwt"
+)
+
+func TestInsecureDecode(t *testing.T) {
+	key := newTestKey(t, "key")
+
+	tests := []struct {
+		name      string
+		token     []byte
+		wantErr   error
+		wantToken bool
+	}{
+		{name: "valid", token: signCompact(t, key.signer, `{"alg":"ES256","kid":"key","typ":"JWT"}`, `{"iss":"https://issuer.example.com","tenant":"acme"}`), wantToken: true},
+		{name: "unknown crit", token: signCompact(t, key.signer, `{"alg":"ES256","kid":"key","crit":["exp"],"exp":1}`, `{"iss":"https://issuer.example.com","tenant":"acme"}`), wantToken: true},
+		{name: "too large", token: bytes.Repeat([]byte("a"), jwt.MaxInsecureTokenSize+1), wantErr: jwt.ErrTokenTooLarge},
+		{name: "malformed", token: []byte("a.b"), wantErr: jwt.ErrMalformedToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := jwt.InsecureDecode(tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("InsecureDecode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (token != nil) != tt.wantToken {
+				t.Fatalf("InsecureDecode() token = %v, wantToken %v", token, tt.wantToken)
+			}
+			if token == nil {
+				return
+			}
+			if token.Verified || token.KeyID != "" {
+				t.Errorf("InsecureDecode() Verified = %v, KeyID = %q", token.Verified, token.KeyID)
+			}
+			if token.Header.Kid() != "key" || token.Algorithm != jwt.ES256 || token.Claims.Issuer != "https://issuer.example.com" || len(token.Signature) != 64 {
+				t.Errorf("InsecureDecode() = %+v", token)
+			}
+			if tenant, err := token.Claims.String("tenant"); err != nil || tenant != "acme" {
+				t.Errorf("InsecureDecode() tenant = %q, %v", tenant, err)
+			}
+		})
+	}
+
+	token := signCompact(t, key.signer, `{"alg":"ES256"}`, `{}`)
+	if _, err := jwt.InsecureDecodeLimit(token, len(token)-1); err != jwt.ErrTokenTooLarge {
+		t.Errorf("InsecureDecodeLimit() error = %v, want %v", err, jwt.ErrTokenTooLarge)
+	}
+}
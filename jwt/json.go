@@ -0,0 +1,235 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+var (
+	// ErrNoSignatures is returned when a JSON serialized token carries no signatures
+	ErrNoSignatures = errors.New("no signatures")
+	// ErrNoSigners is returned when a JSON serialized token is requested without signers
+	ErrNoSigners = errors.New("no signers")
+)
+
+// SignatureStatus is the verification result of a single signature in a
+// JSON serialized token
+type SignatureStatus struct {
+	KeyID     string
+	Algorithm Algorithm
+	Err       error
+}
+
+// Valid reports if the signature was verified
+func (s SignatureStatus) Valid() bool { return s.Err == nil }
+
+type jsonSignature struct {
+	Protected string          `json:"protected,omitempty"`
+	Header    json.RawMessage `json:"header,omitempty"`
+	Signature string          `json:"signature"`
+}
+
+type generalToken struct {
+	Payload    string          `json:"payload"`
+	Signatures []jsonSignature `json:"signatures"`
+}
+
+type flattenedToken struct {
+	Payload string `json:"payload"`
+	jsonSignature
+}
+
+type jsonToken struct {
+	Payload    *string         `json:"payload"`
+	Signatures []jsonSignature `json:"signatures"`
+	Protected  string          `json:"protected"`
+	Header     json.RawMessage `json:"header"`
+	Signature  *string         `json:"signature"`
+}
+
+// MarshalJSON returns the General JWS JSON Serialization (RFC 7515 7.2.1)
+// of the payload with a signature from each of the signers
+func MarshalJSON(rand io.Reader, payload interface{}, signers ...Signer) ([]byte, error) {
+	if len(signers) == 0 {
+		return nil, ErrNoSigners
+	}
+
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	token := generalToken{Signatures: make([]jsonSignature, 0, len(signers))}
+	for _, signer := range signers {
+		encodedPayload, signature, err := signJSON(rand, payloadJSON, signer)
+		if err != nil {
+			return nil, err
+		}
+		token.Payload = encodedPayload
+		token.Signatures = append(token.Signatures, signature)
+	}
+
+	return json.Marshal(token)
+}
+
+// MarshalFlattenedJSON returns the Flattened JWS JSON Serialization
+// (RFC 7515 7.2.2) of the payload signed by signer
+func MarshalFlattenedJSON(rand io.Reader, payload interface{}, signer Signer) ([]byte, error) {
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	encodedPayload, signature, err := signJSON(rand, payloadJSON, signer)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(flattenedToken{Payload: encodedPayload, jsonSignature: signature})
+}
+
+func signJSON(rand io.Reader, payloadJSON []byte, signer Signer) (string, jsonSignature, error) {
+	header := header{
+		Type: "JWT",
+	}
+	token, err := signToken(rand, payloadJSON, &header, signer)
+	if err != nil {
+		return "", jsonSignature{}, err
+	}
+
+	return string(token.payloadSlice), jsonSignature{
+		Protected: string(token.headerSlice),
+		Signature: string(token.signatureSlice),
+	}, nil
+}
+
+// UnmarshalJSON verifies every signature of a General or Flattened JWS JSON
+// Serialization and reports the result of each. The payload is only
+// unmarshaled if at least one signature is valid, otherwise
+// ErrInvalidSignature is returned
+func UnmarshalJSON(b []byte, payload interface{}, verifiers Verifiers) ([]SignatureStatus, error) {
+	payloadbuf, statuses, err := unmarshalJSON(b, verifiers)
+	if err != nil {
+		return statuses, err
+	}
+
+	if err := json.Unmarshal(payloadbuf, payload); err != nil {
+		return statuses, err
+	}
+
+	return statuses, nil
+}
+
+func unmarshalJSON(b []byte, verifiers Verifiers) ([]byte, []SignatureStatus, error) {
+	var token jsonToken
+	if err := json.Unmarshal(b, &token); err != nil {
+		return nil, nil, err
+	}
+
+	if token.Payload == nil {
+		return nil, nil, ErrMalformedToken
+	}
+
+	signatures := token.Signatures
+	if token.Signature != nil {
+		if signatures != nil {
+			return nil, nil, ErrMalformedToken
+		}
+		signatures = []jsonSignature{{
+			Protected: token.Protected,
+			Header:    token.Header,
+			Signature: *token.Signature,
+		}}
+	}
+	if len(signatures) == 0 {
+		return nil, nil, ErrNoSignatures
+	}
+
+	statuses := make([]SignatureStatus, len(signatures))
+	verified := false
+	for i, signature := range signatures {
+		statuses[i] = verifyJSONSignature(*token.Payload, signature, verifiers)
+		if statuses[i].Valid() {
+			verified = true
+		}
+	}
+	if !verified {
+		return nil, statuses, ErrInvalidSignature
+	}
+
+	payloadbuf, err := decodeSegment([]byte(*token.Payload))
+	if err != nil {
+		return nil, statuses, malformed("payload", err)
+	}
+
+	return payloadbuf, statuses, nil
+}
+
+func verifyJSONSignature(encodedPayload string, s jsonSignature, verifiers Verifiers) SignatureStatus {
+	var header header
+	if err := parseJSONHeader(s, &header); err != nil {
+		return SignatureStatus{Err: err}
+	}
+
+	status := SignatureStatus{KeyID: header.Kid(), Algorithm: header.Alg()}
+	if err := checkAlgorithm(&header); err != nil {
+		status.Err = err
+		return status
+	}
+
+	signature, err := decodeSegment([]byte(s.Signature))
+	if err != nil {
+		status.Err = malformed("signature", err)
+		return status
+	}
+
+	signed := []byte(s.Protected + "." + encodedPayload)
+	kid, err := verifiers.Verify(header.Alg(), header.Kid(), signed, signature)
+	if err != nil {
+		status.Err = err
+		return status
+	}
+
+	status.KeyID = kid
+	return status
+}
+
+// parseJSONHeader merges the protected and unprotected header of a signature,
+// which must not share any parameters, into header
+func parseJSONHeader(s jsonSignature, header Header) error {
+	params := map[string]json.RawMessage{}
+
+	if s.Protected != "" {
+		headerbuf, err := decodeSegment([]byte(s.Protected))
+		if err != nil {
+			return malformed("header", err)
+		}
+		if err := json.Unmarshal(headerbuf, &params); err != nil {
+			return malformed("header", err)
+		}
+	}
+
+	if len(s.Header) > 0 {
+		var unprotected map[string]json.RawMessage
+		if err := json.Unmarshal(s.Header, &unprotected); err != nil {
+			return malformed("header", err)
+		}
+		if _, ok := unprotected["crit"]; ok {
+			return malformed("header", ErrMalformedCritical)
+		}
+		for k, v := range unprotected {
+			if _, ok := params[k]; ok {
+				return malformed("header", ErrMalformedHeader)
+			}
+			params[k] = v
+		}
+	}
+
+	headerbuf, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	return parseHeader(headerbuf, header)
+}
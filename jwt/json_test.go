@@ -0,0 +1,126 @@
+package jwt_test
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/KalleDK/go-jwt/jwt"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	oldKey := newTestKey(t, "old")
+	newKey := newTestKey(t, "new")
+	otherKey := newTestKey(t, "other")
+
+	payload := testPayload{Subject: "1234567890", Name: "John Doe"}
+
+	tests := []struct {
+		name      string
+		signers   []jwt.Signer
+		verifiers []jwt.Verifier
+		wantValid []bool
+		wantErr   bool
+	}{
+		{
+			name:      "both valid",
+			signers:   []jwt.Signer{oldKey.signer, newKey.signer},
+			verifiers: []jwt.Verifier{oldKey.verifier, newKey.verifier},
+			wantValid: []bool{true, true},
+		},
+		{
+			name:      "rotation only new key known",
+			signers:   []jwt.Signer{oldKey.signer, newKey.signer},
+			verifiers: []jwt.Verifier{newKey.verifier},
+			wantValid: []bool{false, true},
+		},
+		{
+			name:      "no known keys",
+			signers:   []jwt.Signer{oldKey.signer, newKey.signer},
+			verifiers: []jwt.Verifier{otherKey.verifier},
+			wantValid: []bool{false, false},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := jwt.MarshalJSON(rand.Reader, payload, tt.signers...)
+			if err != nil {
+				t.Fatalf("MarshalJSON() error = %v", err)
+			}
+
+			var got testPayload
+			statuses, err := jwt.UnmarshalJSON(b, &got, jwt.NewVerifiers(true, tt.verifiers...))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnmarshalJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			gotValid := make([]bool, len(statuses))
+			for i, s := range statuses {
+				gotValid[i] = s.Valid()
+				if s.KeyID != tt.signers[i].KeyID() {
+					t.Errorf("UnmarshalJSON() KeyID = %v, want %v", s.KeyID, tt.signers[i].KeyID())
+				}
+			}
+			if !reflect.DeepEqual(gotValid, tt.wantValid) {
+				t.Errorf("UnmarshalJSON() valid = %v, want %v", gotValid, tt.wantValid)
+			}
+			if !tt.wantErr && got != payload {
+				t.Errorf("UnmarshalJSON() payload = %v, want %v", got, payload)
+			}
+		})
+	}
+}
+
+func TestMarshalFlattenedJSON(t *testing.T) {
+	key := newTestKey(t, "key")
+	payload := testPayload{Subject: "1234567890", Name: "John Doe"}
+
+	b, err := jwt.MarshalFlattenedJSON(rand.Reader, payload, key.signer)
+	if err != nil {
+		t.Fatalf("MarshalFlattenedJSON() error = %v", err)
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(b, &members); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := members["signatures"]; ok {
+		t.Errorf("MarshalFlattenedJSON() has signatures member")
+	}
+
+	var got testPayload
+	statuses, err := jwt.UnmarshalJSON(b, &got, key.verifier)
+	if err != nil {
+		t.Fatalf("UnmarshalJSON() error = %v", err)
+	}
+	if len(statuses) != 1 || !statuses[0].Valid() {
+		t.Errorf("UnmarshalJSON() statuses = %+v", statuses)
+	}
+	if got != payload {
+		t.Errorf("UnmarshalJSON() payload = %v, want %v", got, payload)
+	}
+}
+
+func TestUnmarshalJSONMalformed(t *testing.T) {
+	key := newTestKey(t, "key")
+
+	tests := []struct {
+		name string
+		b    []byte
+	}{
+		{name: "no payload", b: []byte(`{"signatures":[{"signature":""}]}`)},
+		{name: "no signatures", b: []byte(`{"payload":"e30","signatures":[]}`)},
+		{name: "general and flattened", b: []byte(`{"payload":"e30","signatures":[],"signature":""}`)},
+		{name: "shared header parameter", b: []byte(`{"payload":"e30","protected":"eyJhbGciOiJFUzI1NiJ9","header":{"alg":"ES256"},"signature":""}`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got testPayload
+			if _, err := jwt.UnmarshalJSON(tt.b, &got, key.verifier); err == nil {
+				t.Errorf("UnmarshalJSON() expected error")
+			}
+		})
+	}
+}
@@ -21,20 +21,28 @@ func Marshal(rand io.Reader, payload interface{}, signer Signer) ([]byte, error)
 }
 
 func MarshalWithHeader(rand io.Reader, payload interface{}, header Header, signer Signer) ([]byte, error) {
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
 
+	token, err := signToken(rand, payloadJSON, header, signer)
+	if err != nil {
+		return nil, err
+	}
+	return token.buffer, nil
+}
+
+func signToken(rand io.Reader, payloadbuf []byte, header Header, signer Signer) (tokenBuffer, error) {
 	header.SetAlg(signer.Algorithm())
 	header.SetKid(signer.KeyID())
 	headerJSON, err := json.Marshal(header)
 	if err != nil {
-		return nil, err
+		return tokenBuffer{}, err
 	}
 	headerSize := len(headerJSON)
 
-	payloadJSON, err := json.Marshal(payload)
-	if err != nil {
-		return nil, err
-	}
-	payloadSize := len(payloadJSON)
+	payloadSize := len(payloadbuf)
 
 	signatureSize := int(signer.Algorithm().SignatureSize())
 
@@ -42,24 +50,39 @@ func MarshalWithHeader(rand io.Reader, payload interface{}, header Header, signe
 
 	// Encode the token
 	encodeSegment(token.headerSlice, headerJSON)
-	encodeSegment(token.payloadSlice, payloadJSON)
+	encodeSegment(token.payloadSlice, payloadbuf)
 
 	// Sign the token
 	signature, err := signer.Sign(rand, token.signedSlice)
 	if err != nil {
-		return nil, err
+		return tokenBuffer{}, err
 	}
 
 	// Encode the signature
-	encodeSegment(token.signatureSlice, signature)
-	return token.buffer, nil
+	return token.withSignature(signature), nil
 }
 
-func Unmarshal(b []byte, payload interface{}, verifiers Verifiers) (string, error) {
+// Unmarshal verifies the token and unmarshals its payload. The registered
+// claims of the payload are then checked by the validators in order
+func Unmarshal(b []byte, payload interface{}, verifiers Verifiers, validators ...ClaimsValidator) (string, error) {
 	var header header
+	return UnmarshalWithHeader(b, payload, &header, verifiers, validators...)
+}
+
+// UnmarshalTyped is Unmarshal for tokens that must have one of the expected
+// types, e.g. "at+jwt" or "dpop+jwt". Pass "" to accept tokens without a typ
+// header. If no types are given any typ is accepted
+func UnmarshalTyped(b []byte, payload interface{}, verifiers Verifiers, types ...string) (string, error) {
+	if len(types) == 0 {
+		var header JOSEHeader
+		return UnmarshalWithHeader(b, payload, &header, verifiers)
+	}
+	header := typedHeader{types: types}
 	return UnmarshalWithHeader(b, payload, &header, verifiers)
 }
 
+// UnmarshalPayload unmarshals the payload WITHOUT verifying the token, see
+// InsecureDecode
 func UnmarshalPayload(b []byte, payload interface{}) error {
 	token, err := parseTokenBuffer(b)
 	if err != nil {
@@ -69,7 +92,7 @@ func UnmarshalPayload(b []byte, payload interface{}) error {
 	return unmarshalPayload(token.payloadSlice, payload)
 }
 
-func UnmarshalWithHeader(b []byte, payload interface{}, header Header, verifiers Verifiers) (string, error) {
+func UnmarshalWithHeader(b []byte, payload interface{}, header Header, verifiers Verifiers, validators ...ClaimsValidator) (string, error) {
 	token, err := parseTokenBuffer(b)
 	if err != nil {
 		return "", err
@@ -84,7 +107,16 @@ func UnmarshalWithHeader(b []byte, payload interface{}, header Header, verifiers
 		return kid, err
 	}
 
-	if err := unmarshalPayload(token.payloadSlice, payload); err != nil {
+	payloadbuf, err := decodeSegment(token.payloadSlice)
+	if err != nil {
+		return kid, malformed("payload", err)
+	}
+
+	if err := json.Unmarshal(payloadbuf, payload); err != nil {
+		return kid, malformed("payload", err)
+	}
+
+	if err := validateClaims(payloadbuf, validators); err != nil {
 		return kid, err
 	}
 
@@ -92,9 +124,12 @@ func UnmarshalWithHeader(b []byte, payload interface{}, header Header, verifiers
 }
 
 func unmarshalSignature(header Header, verifiers Verifiers, signedSlice []byte, signatureSlice []byte) (string, error) {
+	if err := checkAlgorithm(header); err != nil {
+		return "", err
+	}
 	signature, err := decodeSegment(signatureSlice)
 	if err != nil {
-		return "", err
+		return "", malformed("signature", err)
 	}
 	return verifiers.Verify(header.Alg(), header.Kid(), signedSlice, signature)
 }
@@ -102,13 +137,20 @@ func unmarshalSignature(header Header, verifiers Verifiers, signedSlice []byte,
 func unmarshalHeader(headerSlice []byte, header Header) error {
 	headerbuf, err := decodeSegment(headerSlice)
 	if err != nil {
-		return err
+		return malformed("header", err)
 	}
+	return parseHeader(headerbuf, header)
+}
+
+func parseHeader(headerbuf []byte, header Header) error {
 	if err := json.Unmarshal(headerbuf, &header); err != nil {
-		return err
+		return malformed("header", err)
+	}
+	if err := checkCritical(headerbuf, header); err != nil {
+		return malformed("header", err)
 	}
 	if err := header.Valid(); err != nil {
-		return err
+		return malformed("header", err)
 	}
 	return nil
 }
@@ -116,10 +158,10 @@ func unmarshalHeader(headerSlice []byte, header Header) error {
 func unmarshalPayload(payloadSlice []byte, payload interface{}) error {
 	payloadbuf, err := decodeSegment(payloadSlice)
 	if err != nil {
-		return err
+		return malformed("payload", err)
 	}
 	if err := json.Unmarshal(payloadbuf, payload); err != nil {
-		return err
+		return malformed("payload", err)
 	}
 	return nil
 }
@@ -137,7 +179,7 @@ func NewVerifiers(keyIDMustMatch bool, vs ...Verifier) Verifiers {
 		if kid != "" {
 			kids[kid] = v
 		}
-		vlist = append(vs, v)
+		vlist = append(vlist, v)
 	}
 	return verifiers{kids: kids, vlist: vlist, keyIDMustMatch: keyIDMustMatch}
 }
@@ -149,10 +191,26 @@ type verifiers struct {
 }
 
 func (vs verifiers) Verify(a Algorithm, kidSuggest string, signed, signature []byte) (kidUsed string, err error) {
+	return vs.verify(a, kidSuggest, func(v Verifier) (string, error) {
+		return v.Verify(a, kidSuggest, signed, signature)
+	})
+}
+
+func (vs verifiers) VerifyDigest(a Algorithm, kidSuggest string, digest, signature []byte) (kidUsed string, err error) {
+	return vs.verify(a, kidSuggest, func(v Verifier) (string, error) {
+		dv, ok := v.(DigestVerifiers)
+		if !ok {
+			return "", ErrStreamingUnsupported
+		}
+		return dv.VerifyDigest(a, kidSuggest, digest, signature)
+	})
+}
+
+func (vs verifiers) verify(a Algorithm, kidSuggest string, verify func(v Verifier) (string, error)) (kidUsed string, err error) {
 	v, ok := vs.kids[kidSuggest]
 
 	if ok && v.Algorithm() == a {
-		kidUsed, err = v.Verify(a, kidSuggest, signed, signature)
+		kidUsed, err = verify(v)
 		if err == nil {
 			return kidUsed, nil
 		}
@@ -161,15 +219,23 @@ func (vs verifiers) Verify(a Algorithm, kidSuggest string, signed, signature []b
 		}
 	}
 	if vs.keyIDMustMatch {
-		return "", ErrNoVerifiersWithKID
+		if ok {
+			return "", &UnsupportedAlgorithmError{Algorithm: a.String()}
+		}
+		return "", &KeyNotFoundError{KeyID: kidSuggest}
 	}
 
+	tried := false
 	for _, v := range vs.vlist {
 		if v.Algorithm() == a {
-			if kidUsed, err := v.Verify(a, kidSuggest, signed, signature); err == nil {
+			tried = true
+			if kidUsed, err := verify(v); err == nil {
 				return kidUsed, nil
 			}
 		}
 	}
-	return "", ErrInvalidSignature
+	if !tried {
+		return "", &UnsupportedAlgorithmError{Algorithm: a.String()}
+	}
+	return "", &SignatureError{KeyID: kidSuggest, Algorithm: a}
 }
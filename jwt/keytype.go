@@ -1,6 +1,7 @@
 package jwt
 
 import (
+	"crypto"
 	"errors"
 	"strconv"
 )
@@ -10,6 +11,12 @@ type KeyParser interface {
 	ParseVerifier(kid string, b []byte) (Verifier, error)
 }
 
+// PublicKeyParser is implemented by key parsers that can parse the public key
+// of a JWK without requiring it to be usable for a specific algorithm
+type PublicKeyParser interface {
+	ParsePublicKey(b []byte) (crypto.PublicKey, error)
+}
+
 type KeyType uint8
 
 const (
@@ -60,6 +67,20 @@ func (k KeyType) ParseVerifier(kid string, b []byte) (Verifier, error) {
 	return f.ParseVerifier(kid, b)
 }
 
+func (k KeyType) ParsePublicKey(b []byte) (crypto.PublicKey, error) {
+	f, err := k.getKeyParser()
+	if err != nil {
+		return nil, err
+	}
+
+	pf, ok := f.(PublicKeyParser)
+	if !ok {
+		return nil, errors.New("jwt: requested key type #" + strconv.Itoa(int(k)) + " can not parse public keys")
+	}
+
+	return pf.ParsePublicKey(b)
+}
+
 func GetKeyType(s string) KeyType {
 	switch s {
 	case "EC":
@@ -0,0 +1,151 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidNumericDate is returned when a NumericDate is malformed or out of range
+var ErrInvalidNumericDate = errors.New("invalid numeric date")
+
+// maxNumericDate is 9999-12-31T23:59:59Z, the latest accepted NumericDate
+const maxNumericDate = 253402300799
+
+// NumericDate is a time encoded as seconds since the epoch (RFC 7519 2).
+// Integer and fractional values are accepted, the latter with up to nanosecond
+// precision. It is marshaled with as many fractional digits as the time has,
+// so a NumericDate is marshaled back as it was unmarshaled
+type NumericDate struct {
+	time.Time
+}
+
+// NewNumericDate returns a NumericDate of t truncated to whole seconds, as
+// most verifiers expect. See NewNumericDateWithPrecision for fractional dates
+func NewNumericDate(t time.Time) *NumericDate {
+	return NewNumericDateWithPrecision(t, time.Second)
+}
+
+// NewNumericDateWithPrecision returns a NumericDate of t truncated to a
+// multiple of precision, e.g. time.Millisecond, which sets the number of
+// fractional digits it is marshaled with. A precision of zero keeps t as it is
+func NewNumericDateWithPrecision(t time.Time, precision time.Duration) *NumericDate {
+	return &NumericDate{t.Truncate(precision)}
+}
+
+func (d NumericDate) MarshalJSON() ([]byte, error) {
+	secs := d.Unix()
+	if secs < 0 || secs > maxNumericDate {
+		return nil, ErrInvalidNumericDate
+	}
+	return []byte(formatNumericDate(secs, int64(d.Nanosecond()))), nil
+}
+
+func (d *NumericDate) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if s == "null" {
+		return nil
+	}
+	if strings.HasPrefix(s, `"`) {
+		return ErrInvalidNumericDate
+	}
+
+	secs, nanos, err := parseNumericDate(s)
+	if err != nil {
+		return err
+	}
+	if secs < 0 || secs > maxNumericDate {
+		return ErrInvalidNumericDate
+	}
+
+	d.Time = time.Unix(secs, nanos)
+	return nil
+}
+
+// formatNumericDate formats secs with the fractional digits of nanos, if any
+func formatNumericDate(secs int64, nanos int64) string {
+	s := strconv.FormatInt(secs, 10)
+	if nanos == 0 {
+		return s
+	}
+	frac := strconv.FormatInt(1000000000+nanos, 10)[1:]
+	return s + "." + strings.TrimRight(frac, "0")
+}
+
+// unquoteNumericDates replaces exp, nbf and iat claims encoded as JSON
+// strings, as produced by some issuers, with numbers
+func unquoteNumericDates(payload []byte) ([]byte, error) {
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &all); err != nil {
+		return nil, err
+	}
+
+	unquoted := false
+	for _, name := range []string{"exp", "nbf", "iat"} {
+		raw, ok := all[name]
+		if !ok || !strings.HasPrefix(string(raw), `"`) {
+			continue
+		}
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, err
+		}
+		secs, nanos, err := parseNumericDate(strings.TrimSpace(s))
+		if err != nil {
+			return nil, err
+		}
+		if secs < 0 || secs > maxNumericDate {
+			return nil, ErrInvalidNumericDate
+		}
+		all[name] = json.RawMessage(formatNumericDate(secs, nanos))
+		unquoted = true
+	}
+	if !unquoted {
+		return payload, nil
+	}
+	return json.Marshal(all)
+}
+
+// parseNumericDate parses integers and decimal fractions exactly, and falls
+// back to floating point for exponent notation
+func parseNumericDate(s string) (int64, int64, error) {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i, 0, nil
+	}
+
+	parts := strings.SplitN(s, ".", 2)
+	if len(parts) == 2 && isDigits(parts[0]) && isDigits(parts[1]) {
+		secs, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			return 0, 0, ErrInvalidNumericDate
+		}
+		frac := (parts[1] + "000000000")[:9]
+		nanos, err := strconv.ParseInt(frac, 10, 64)
+		if err != nil {
+			return 0, 0, ErrInvalidNumericDate
+		}
+		return secs, nanos, nil
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) || f < 0 || f > maxNumericDate {
+		return 0, 0, ErrInvalidNumericDate
+	}
+	secs := math.Floor(f)
+	return int64(secs), int64(math.Round((f - secs) * 1e9)), nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
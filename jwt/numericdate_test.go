@@ -0,0 +1,128 @@
+package jwt_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/KalleDK/go-jwt/jwt"
+)
+
+func TestNumericDateUnmarshal(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "integer", json: `1516239022`, want: time.Unix(1516239022, 0)},
+		{name: "fraction", json: `1516239022.123456789`, want: time.Unix(1516239022, 123456789)},
+		{name: "short fraction", json: `1516239022.5`, want: time.Unix(1516239022, 500000000)},
+		{name: "exponent", json: `1.516239022e9`, want: time.Unix(1516239022, 0)},
+		{name: "string rejected", json: `"1516239022"`, wantErr: true},
+		{name: "negative", json: `-1`, wantErr: true},
+		{name: "beyond year 9999", json: `253402300800`, wantErr: true},
+		{name: "huge float", json: `1e300`, wantErr: true},
+		{name: "overflow", json: `99999999999999999999`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d jwt.NumericDate
+			err := json.Unmarshal([]byte(tt.json), &d)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !d.Equal(tt.want) {
+				t.Errorf("Unmarshal() = %v, want %v", d.Time, tt.want)
+			}
+		})
+	}
+}
+
+func TestNumericDateMarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		date *jwt.NumericDate
+		want string
+	}{
+		{name: "NewNumericDate", date: jwt.NewNumericDate(time.Unix(1516239022, 123456789)), want: `1516239022`},
+		{name: "millisecond precision", date: jwt.NewNumericDateWithPrecision(time.Unix(1516239022, 123456789), time.Millisecond), want: `1516239022.123`},
+		{name: "microsecond precision", date: jwt.NewNumericDateWithPrecision(time.Unix(1516239022, 123456789), time.Microsecond), want: `1516239022.123456`},
+		{name: "no precision", date: jwt.NewNumericDateWithPrecision(time.Unix(1516239022, 123456789), 0), want: `1516239022.123456789`},
+		{name: "seconds", date: &jwt.NumericDate{Time: time.Unix(1516239022, 0)}, want: `1516239022`},
+		{name: "milliseconds", date: &jwt.NumericDate{Time: time.Unix(1516239022, 123000000)}, want: `1516239022.123`},
+		{name: "microseconds", date: &jwt.NumericDate{Time: time.Unix(1516239022, 123456000)}, want: `1516239022.123456`},
+		{name: "nanoseconds", date: &jwt.NumericDate{Time: time.Unix(1516239022, 123456789)}, want: `1516239022.123456789`},
+		{name: "leading zero", date: &jwt.NumericDate{Time: time.Unix(1516239022, 5000000)}, want: `1516239022.005`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := json.Marshal(jwt.RegisteredClaims{ExpiresAt: tt.date})
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if want := `{"exp":` + tt.want + `}`; string(b) != want {
+				t.Errorf("Marshal() = %s, want %s", b, want)
+			}
+
+			var claims jwt.RegisteredClaims
+			if err := json.Unmarshal(b, &claims); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !claims.ExpiresAt.Equal(tt.date.Time) {
+				t.Errorf("Unmarshal() = %v, want %v", claims.ExpiresAt.Time, tt.date.Time)
+			}
+		})
+	}
+}
+
+func TestWithNumericDateStrings(t *testing.T) {
+	key := newTestKey(t, "key")
+	now := time.Unix(1516239022, 0)
+
+	tests := []struct {
+		name    string
+		payload string
+		opts    []jwt.ParserOption
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "string rejected", payload: `{"exp":"1516239122"}`, wantErr: true},
+		{name: "string allowed", payload: `{"exp":"1516239122.25"}`, opts: []jwt.ParserOption{jwt.WithNumericDateStrings()}, want: time.Unix(1516239122, 250000000)},
+		{name: "number allowed", payload: `{"exp":1516239122}`, opts: []jwt.ParserOption{jwt.WithNumericDateStrings()}, want: time.Unix(1516239122, 0)},
+		{name: "not a number", payload: `{"exp":"soon"}`, opts: []jwt.ParserOption{jwt.WithNumericDateStrings()}, wantErr: true},
+		{name: "expired string", payload: `{"exp":"1516239000"}`, opts: []jwt.ParserOption{jwt.WithNumericDateStrings()}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := signCompact(t, key.signer, `{"alg":"ES256","typ":"JWT"}`, tt.payload)
+			opts := append([]jwt.ParserOption{jwt.WithClock(func() time.Time { return now })}, tt.opts...)
+
+			var claims jwt.RegisteredClaims
+			_, err := jwt.NewParser(opts...).Unmarshal(b, &claims, key.verifier)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !claims.ExpiresAt.Equal(tt.want) {
+				t.Errorf("Unmarshal() exp = %v, want %v", claims.ExpiresAt.Time, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithNumericDateStringsPayload(t *testing.T) {
+	key := newTestKey(t, "key")
+	payload := `{"exp":"1516239122", "sub":"sub"}`
+	b := signCompact(t, key.signer, `{"alg":"ES256","typ":"JWT"}`, payload)
+
+	token, err := jwt.Parse(b, key.verifier, jwt.WithNumericDateStrings(), jwt.WithClock(func() time.Time { return time.Unix(1516239022, 0) }))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if string(token.Payload) != payload {
+		t.Errorf("Parse() payload = %s, want %s", token.Payload, payload)
+	}
+	if exp := token.Claims.ExpiresAt; exp == nil || !exp.Equal(time.Unix(1516239122, 0)) {
+		t.Errorf("Parse() exp = %v, want %v", exp, time.Unix(1516239122, 0))
+	}
+}
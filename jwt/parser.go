@@ -0,0 +1,265 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrMissingClaim is returned when a required claim is absent
+var ErrMissingClaim = errors.New("missing claim")
+
+// ValidatorFunc validates a token once its signature is verified. It is given
+// the header, the registered claims and the decoded payload
+type ValidatorFunc func(header *JOSEHeader, claims *RegisteredClaims, payload []byte) error
+
+// ParserOption configures a Parser
+type ParserOption func(p *Parser)
+
+// Parser verifies tokens and validates their header and claims. A token is
+// checked in this order, stopping at the first error:
+//
+//  1. the crit header
+//  2. the typ header against the expected types, "JWT" by default
+//  3. the alg header against the allowed algorithms, if any are set
+//  4. the signature
+//  5. the presence of the required claims
+//  6. the exp, nbf and iat claims against the clock, allowing for leeway
+//  7. the validators, in the order their options were given
+//  8. the jti claim against the replay cache, if one is set
+type Parser struct {
+	algorithms []Algorithm
+	types      []string
+	required   []string
+	validators []ValidatorFunc
+	leeway     time.Duration
+	now        func() time.Time
+	// dateStrings accepts exp, nbf and iat claims encoded as strings
+	dateStrings bool
+	// replay is run last, so only tokens which are otherwise valid are recorded
+	replay ValidatorFunc
+}
+
+// NewParser returns a Parser configured by the options
+func NewParser(opts ...ParserOption) *Parser {
+	p := &Parser{
+		types: []string{"JWT"},
+		now:   time.Now,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// WithAlgorithms only allows tokens signed with one of the algorithms
+func WithAlgorithms(algs ...Algorithm) ParserOption {
+	return func(p *Parser) { p.algorithms = algs }
+}
+
+// WithTypes only allows tokens with one of the types, see MatchType. If no
+// types are given any typ is accepted
+func WithTypes(types ...string) ParserOption {
+	return func(p *Parser) { p.types = types }
+}
+
+// WithRequiredClaims requires the named claims to be present
+func WithRequiredClaims(names ...string) ParserOption {
+	return func(p *Parser) { p.required = append(p.required, names...) }
+}
+
+// WithLeeway allows the clocks of the issuer and the parser to differ by d
+func WithLeeway(d time.Duration) ParserOption {
+	return func(p *Parser) { p.leeway = d }
+}
+
+// WithClock sets the function returning the current time
+func WithClock(now func() time.Time) ParserOption {
+	return func(p *Parser) { p.now = now }
+}
+
+// WithNumericDateStrings accepts exp, nbf and iat claims encoded as JSON
+// strings, as produced by some issuers. They are replaced by numbers in a copy
+// of the payload, which is what is unmarshaled and validated, while the
+// Payload of the Token is left as it was signed
+func WithNumericDateStrings() ParserOption {
+	return func(p *Parser) { p.dateStrings = true }
+}
+
+// WithValidator adds a validator run after the built-in checks
+func WithValidator(f ValidatorFunc) ParserOption {
+	return func(p *Parser) { p.validators = append(p.validators, f) }
+}
+
+// WithClaimsValidator adds a ClaimsValidator run after the built-in checks
+func WithClaimsValidator(v ClaimsValidator) ParserOption {
+	return WithValidator(func(header *JOSEHeader, claims *RegisteredClaims, payload []byte) error {
+		return v.ValidateClaims(claims)
+	})
+}
+
+// WithIssuer requires the iss claim to be issuer
+func WithIssuer(issuer string) ParserOption {
+	return WithClaimsValidator(IssuerValidator{Issuer: issuer})
+}
+
+// WithAudience requires the aud claim to contain at least one of the audiences
+func WithAudience(audiences ...string) ParserOption {
+	return WithClaimsValidator(AudienceValidator{Audiences: audiences})
+}
+
+// Parse verifies and validates the token. A malformed token returns a nil
+// Token, otherwise the Token is returned even with an error, and Verified
+// reports if its signature was verified
+func Parse(b []byte, verifiers Verifiers, opts ...ParserOption) (*Token, error) {
+	return NewParser(opts...).Parse(b, verifiers)
+}
+
+// Parse verifies and validates the token, see the package function Parse
+func (p *Parser) Parse(b []byte, verifiers Verifiers) (*Token, error) {
+	return p.parse(b, func(header *JOSEHeader) (Verifiers, error) {
+		return verifiers, nil
+	})
+}
+
+// parse is Parse with the verifiers resolved from the header once it is
+// validated
+func (p *Parser) parse(b []byte, resolve ResolverFunc) (*Token, error) {
+	token, err := newToken(b)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := unmarshalHeader(token.RawHeader, &token.Header); err != nil {
+		return nil, err
+	}
+	token.Algorithm = token.Header.Alg()
+
+	if token.Signature, err = decodeSegment(token.RawSignature); err != nil {
+		return nil, malformed("signature", err)
+	}
+	if token.Payload, err = decodeSegment(token.RawPayload); err != nil {
+		return nil, malformed("payload", err)
+	}
+
+	if err := p.validateHeader(&token.Header); err != nil {
+		return token, err
+	}
+	if err := checkAlgorithm(&token.Header); err != nil {
+		return token, err
+	}
+
+	verifiers, err := resolve(&token.Header)
+	if err != nil {
+		return token, err
+	}
+
+	token.KeyID, err = verifiers.Verify(token.Algorithm, token.Header.Kid(), token.SigningInput, token.Signature)
+	if err != nil {
+		return token, err
+	}
+	token.Verified = true
+
+	token.claims = token.Payload
+	if p.dateStrings {
+		if token.claims, err = unquoteNumericDates(token.Payload); err != nil {
+			return token, malformed("payload", err)
+		}
+	}
+
+	if err := json.Unmarshal(token.claims, &token.Claims); err != nil {
+		return token, malformed("payload", err)
+	}
+
+	if err := p.validateClaims(&token.Header, token.claims); err != nil {
+		return token, err
+	}
+
+	return token, nil
+}
+
+// Unmarshal verifies and validates the token and unmarshals its payload
+func (p *Parser) Unmarshal(b []byte, payload interface{}, verifiers Verifiers) (string, error) {
+	var header JOSEHeader
+	return p.UnmarshalWithHeader(b, payload, &header, verifiers)
+}
+
+// UnmarshalWithHeader is Unmarshal which also unmarshals the header into header
+func (p *Parser) UnmarshalWithHeader(b []byte, payload interface{}, header *JOSEHeader, verifiers Verifiers) (string, error) {
+	token, err := p.Parse(b, verifiers)
+	return unmarshalToken(token, err, payload, header)
+}
+
+// UnmarshalResolved is UnmarshalWithHeader with the verifiers resolved from
+// the header, once its typ and alg are validated
+func (p *Parser) UnmarshalResolved(b []byte, payload interface{}, header *JOSEHeader, resolver Resolver) (string, error) {
+	token, err := p.parse(b, resolver.Resolve)
+	return unmarshalToken(token, err, payload, header)
+}
+
+func unmarshalToken(token *Token, err error, payload interface{}, header *JOSEHeader) (string, error) {
+	if token == nil {
+		return "", err
+	}
+	*header = token.Header
+	if err != nil {
+		return token.KeyID, err
+	}
+
+	if err := json.Unmarshal(token.claims, payload); err != nil {
+		return token.KeyID, malformed("payload", err)
+	}
+
+	return token.KeyID, nil
+}
+
+func (p *Parser) validateHeader(header *JOSEHeader) error {
+	if len(p.types) > 0 && !MatchType(header.Typ(), p.types...) {
+		return malformed("header", ErrInvalidHeaderType)
+	}
+
+	if len(p.algorithms) > 0 {
+		alg := header.Alg()
+		for _, a := range p.algorithms {
+			if a == alg {
+				return nil
+			}
+		}
+		return &UnsupportedAlgorithmError{Algorithm: header.Algorithm}
+	}
+	return nil
+}
+
+func (p *Parser) validateClaims(header *JOSEHeader, payloadbuf []byte) error {
+	if len(p.required) > 0 {
+		var present map[string]json.RawMessage
+		if err := json.Unmarshal(payloadbuf, &present); err != nil {
+			return err
+		}
+		for _, name := range p.required {
+			if _, ok := present[name]; !ok {
+				return &ClaimError{Claim: name, Err: ErrMissingClaim}
+			}
+		}
+	}
+
+	var claims RegisteredClaims
+	if err := json.Unmarshal(payloadbuf, &claims); err != nil {
+		return err
+	}
+
+	if err := (TimeValidator{Leeway: p.leeway, Now: p.now}).ValidateClaims(&claims); err != nil {
+		return err
+	}
+
+	for _, v := range p.validators {
+		if err := v(header, &claims, payloadbuf); err != nil {
+			return err
+		}
+	}
+
+	if p.replay != nil {
+		return p.replay(header, &claims, payloadbuf)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package jwt_test
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/KalleDK/go-jwt/jwt"
+)
+
+func TestParser(t *testing.T) {
+	key := newTestKey(t, "key")
+	now := time.Unix(1600000000, 0)
+	clock := func() time.Time { return now }
+
+	type claims struct {
+		jwt.RegisteredClaims
+		Tenant string `json:"tenant,omitempty"`
+	}
+
+	valid := claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "https://issuer.example.com",
+			Audience:  jwt.Audience{"api"},
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		},
+		Tenant: "acme",
+	}
+	expired := valid
+	expired.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Minute))
+
+	errTenant := errors.New("wrong tenant")
+	tenant := func(header *jwt.JOSEHeader, c *jwt.RegisteredClaims, payload []byte) error {
+		if header.Kid() != "key" {
+			return errors.New("wrong kid")
+		}
+		var p claims
+		if err := json.Unmarshal(payload, &p); err != nil {
+			return err
+		}
+		if p.Tenant != "acme" {
+			return errTenant
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name    string
+		claims  claims
+		opts    []jwt.ParserOption
+		wantErr error
+	}{
+		{
+			name:   "all options",
+			claims: valid,
+			opts: []jwt.ParserOption{
+				jwt.WithAlgorithms(jwt.ES256),
+				jwt.WithTypes("JWT"),
+				jwt.WithRequiredClaims("exp", "tenant"),
+				jwt.WithIssuer("https://issuer.example.com"),
+				jwt.WithAudience("api"),
+				jwt.WithValidator(tenant),
+			},
+		},
+		{name: "algorithm not allowed", claims: valid, opts: []jwt.ParserOption{jwt.WithAlgorithms(jwt.RS256)}, wantErr: jwt.ErrUnsupportedAlgorithm},
+		{name: "type not expected", claims: valid, opts: []jwt.ParserOption{jwt.WithTypes("at+jwt")}, wantErr: jwt.ErrInvalidHeaderType},
+		{name: "missing claim", claims: valid, opts: []jwt.ParserOption{jwt.WithRequiredClaims("sub")}, wantErr: jwt.ErrMissingClaim},
+		{name: "expired", claims: expired, wantErr: jwt.ErrTokenExpired},
+		{name: "expired within leeway", claims: expired, opts: []jwt.ParserOption{jwt.WithLeeway(2 * time.Minute)}},
+		{name: "wrong issuer", claims: valid, opts: []jwt.ParserOption{jwt.WithIssuer("https://other.example.com")}, wantErr: jwt.ErrInvalidIssuer},
+		{name: "wrong audience", claims: valid, opts: []jwt.ParserOption{jwt.WithAudience("web")}, wantErr: jwt.ErrInvalidAudience},
+		{name: "time checked before validators", claims: expired, opts: []jwt.ParserOption{jwt.WithIssuer("https://other.example.com")}, wantErr: jwt.ErrTokenExpired},
+		{
+			name:    "validators run in order",
+			claims:  claims{RegisteredClaims: valid.RegisteredClaims, Tenant: "other"},
+			opts:    []jwt.ParserOption{jwt.WithValidator(tenant), jwt.WithIssuer("https://other.example.com")},
+			wantErr: errTenant,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := jwt.Marshal(rand.Reader, tt.claims, key.signer)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			p := jwt.NewParser(append([]jwt.ParserOption{jwt.WithClock(clock)}, tt.opts...)...)
+			var got claims
+			_, err = p.Unmarshal(b, &got, key.verifier)
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Errorf("Parser.Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,406 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxDescription limits the length of values quoted in errors
+const maxDescription = 64
+
+type env struct {
+	claims map[string]interface{}
+	vars   map[string]interface{}
+	now    time.Time
+}
+
+// test returns nil if n holds, and an Error naming the clause otherwise. The
+// Error is indeterminate if n could not be evaluated, e.g. as a claim is
+// missing, and such an Error is never negated
+func (e env) test(n *node) error {
+	switch n.kind {
+	case nodeAnd:
+		if err := e.test(n.children[0]); err != nil {
+			return err
+		}
+		return e.test(n.children[1])
+
+	case nodeOr:
+		lerr := e.test(n.children[0])
+		if lerr == nil {
+			return nil
+		}
+		rerr := e.test(n.children[1])
+		if rerr == nil {
+			return nil
+		}
+		return &Error{Clause: n.src, Reason: reason(lerr) + ", and " + reason(rerr), indeterminate: indeterminate(lerr) || indeterminate(rerr)}
+
+	case nodeNot:
+		err := e.test(n.children[0])
+		if err == nil {
+			return &Error{Clause: n.src, Reason: n.children[0].src + " holds"}
+		}
+		if indeterminate(err) {
+			return err
+		}
+		return nil
+
+	case nodeCompare:
+		l, err := e.eval(n.children[0])
+		if err != nil {
+			return undecided(n, err)
+		}
+		r, err := e.eval(n.children[1])
+		if err != nil {
+			return undecided(n, err)
+		}
+		ok, err := compare(n.op, l, r)
+		if err != nil {
+			return undecided(n, err)
+		}
+		if !ok {
+			return &Error{Clause: n.src, Reason: describe(l) + " " + negate(n.op) + " " + describe(r)}
+		}
+		return nil
+
+	default:
+		v, err := e.eval(n)
+		if err != nil {
+			return undecided(n, err)
+		}
+		switch v {
+		case true:
+			return nil
+		case false:
+			return &Error{Clause: n.src, Reason: describe(v) + " is not true"}
+		}
+		return &Error{Clause: n.src, Reason: describe(v) + " is not a boolean", indeterminate: true}
+	}
+}
+
+// eval returns the value of n. Numbers are float64, or bigInteger for integers
+// a float64 can not represent, lists []interface{} and objects
+// map[string]interface{}
+func (e env) eval(n *node) (interface{}, error) {
+	switch n.kind {
+	case nodeLiteral:
+		return n.value, nil
+
+	case nodeNow:
+		return float64(e.now.UnixNano()) / 1e9, nil
+
+	case nodeClaim:
+		v, ok := lookup(e.claims, n.path)
+		if !ok {
+			return nil, errors.New(n.src + " is missing")
+		}
+		return v, nil
+
+	case nodeVar:
+		v, ok := lookup(e.vars, n.path)
+		if !ok {
+			return nil, errors.New(n.src + " is not set")
+		}
+		return v, nil
+
+	case nodeList:
+		l := make([]interface{}, len(n.children))
+		for i, c := range n.children {
+			v, err := e.eval(c)
+			if err != nil {
+				return nil, err
+			}
+			l[i] = v
+		}
+		return l, nil
+
+	case nodeNeg:
+		v, err := e.eval(n.children[0])
+		if err != nil {
+			return nil, err
+		}
+		f, ok := v.(float64)
+		if !ok {
+			return nil, errors.New(n.children[0].src + " is not a number")
+		}
+		return -f, nil
+
+	case nodeArith:
+		l, err := e.eval(n.children[0])
+		if err != nil {
+			return nil, err
+		}
+		r, err := e.eval(n.children[1])
+		if err != nil {
+			return nil, err
+		}
+		lf, lok := l.(float64)
+		rf, rok := r.(float64)
+		if !lok || !rok {
+			return nil, errors.New(n.src + " requires numbers")
+		}
+		if n.op == "+" {
+			return lf + rf, nil
+		}
+		return lf - rf, nil
+
+	default:
+		err := e.test(n)
+		if indeterminate(err) {
+			return nil, err
+		}
+		return err == nil, nil
+	}
+}
+
+// undecided returns an indeterminate Error for n, which could not be evaluated
+// due to err
+func undecided(n *node, err error) error {
+	var e *Error
+	if errors.As(err, &e) && e.indeterminate {
+		return err
+	}
+	return &Error{Clause: n.src, Reason: err.Error(), indeterminate: true}
+}
+
+func indeterminate(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.indeterminate
+}
+
+// lookup returns the member at path, normalized like eval
+func lookup(root map[string]interface{}, path []string) (interface{}, bool) {
+	var v interface{} = root
+	if root == nil {
+		return nil, false
+	}
+	for _, name := range path {
+		m, ok := normalize(v).(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if v, ok = m[name]; !ok {
+			return nil, false
+		}
+	}
+	return normalize(v), true
+}
+
+// maxExactInteger is the largest integer up to which every integer is exactly
+// representable as a float64
+const maxExactInteger = 1 << 53
+
+// bigInteger is an integer which a float64 can not represent exactly, in
+// decimal. It only equals the same integer, and can not be ordered or used in
+// arithmetic, as it could not be done exactly
+type bigInteger string
+
+func (b bigInteger) MarshalJSON() ([]byte, error) { return []byte(b), nil }
+
+// integer returns the decimal integer s as a float64, or as a bigInteger if it
+// is not exactly representable. ok is false if s is not an integer
+func integer(s string) (v interface{}, ok bool) {
+	i, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, false
+	}
+	if i.IsInt64() && i.Int64() >= -maxExactInteger && i.Int64() <= maxExactInteger {
+		return float64(i.Int64()), true
+	}
+	return bigInteger(i.String()), true
+}
+
+// normalize converts numbers to float64 or bigInteger, slices to
+// []interface{} and maps with string keys to map[string]interface{}
+func normalize(v interface{}) interface{} {
+	switch x := v.(type) {
+	case nil, bool, string, float64, bigInteger, []interface{}, map[string]interface{}:
+		return v
+	case json.Number:
+		if i, ok := integer(string(x)); ok {
+			return i
+		}
+		f, err := x.Float64()
+		if err != nil {
+			return v
+		}
+		return f
+	case []string:
+		l := make([]interface{}, len(x))
+		for i, s := range x {
+			l[i] = s
+		}
+		return l
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, _ := integer(strconv.FormatInt(rv.Int(), 10))
+		return i
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, _ := integer(strconv.FormatUint(rv.Uint(), 10))
+		return i
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.Slice, reflect.Array:
+		l := make([]interface{}, rv.Len())
+		for i := range l {
+			l[i] = rv.Index(i).Interface()
+		}
+		return l
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return v
+		}
+		m := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			m[iter.Key().String()] = iter.Value().Interface()
+		}
+		return m
+	}
+	return v
+}
+
+func compare(op string, l, r interface{}) (bool, error) {
+	switch op {
+	case "==":
+		return equal(l, r), nil
+	case "!=":
+		return !equal(l, r), nil
+	case "in":
+		return member(l, r)
+	case "contains":
+		return member(r, l)
+	}
+
+	switch lv := l.(type) {
+	case float64:
+		rv, ok := r.(float64)
+		if !ok {
+			return false, errors.New("can not compare " + describe(l) + " with " + describe(r))
+		}
+		switch op {
+		case "<":
+			return lv < rv, nil
+		case "<=":
+			return lv <= rv, nil
+		case ">":
+			return lv > rv, nil
+		default:
+			return lv >= rv, nil
+		}
+	case string:
+		rv, ok := r.(string)
+		if !ok {
+			return false, errors.New("can not compare " + describe(l) + " with " + describe(r))
+		}
+		switch op {
+		case "<":
+			return lv < rv, nil
+		case "<=":
+			return lv <= rv, nil
+		case ">":
+			return lv > rv, nil
+		default:
+			return lv >= rv, nil
+		}
+	}
+	return false, errors.New("can not compare " + describe(l) + " with " + describe(r))
+}
+
+func equal(l, r interface{}) bool {
+	l = normalize(l)
+	r = normalize(r)
+	ll, lok := l.([]interface{})
+	rl, rok := r.([]interface{})
+	if lok && rok {
+		if len(ll) != len(rl) {
+			return false
+		}
+		for i := range ll {
+			if !equal(ll[i], rl[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(l, r)
+}
+
+// member reports if v is an element of the list, or a word of the
+// space-delimited string, set
+func member(v, set interface{}) (bool, error) {
+	switch s := set.(type) {
+	case []interface{}:
+		for _, item := range s {
+			if equal(v, item) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case string:
+		word, ok := v.(string)
+		if !ok {
+			return false, nil
+		}
+		for _, w := range strings.Fields(s) {
+			if w == word {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return false, errors.New(describe(set) + " is not a list or string")
+}
+
+func negate(op string) string {
+	switch op {
+	case "==":
+		return "!="
+	case "!=":
+		return "=="
+	case "<":
+		return ">="
+	case "<=":
+		return ">"
+	case ">":
+		return "<="
+	case ">=":
+		return "<"
+	case "in":
+		return "not in"
+	default:
+		return "does not contain"
+	}
+}
+
+func describe(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "value"
+	}
+	if len(b) > maxDescription {
+		return string(b[:maxDescription]) + "..."
+	}
+	return string(b)
+}
+
+func reason(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Clause + ": " + e.Reason
+	}
+	return err.Error()
+}
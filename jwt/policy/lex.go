@@ -0,0 +1,142 @@
+package policy
+
+import (
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenVar
+	tokenString
+	tokenNumber
+	tokenPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+	str  string
+	num  float64
+}
+
+// units are the suffixes of duration literals, in seconds
+var units = map[byte]float64{
+	's': 1,
+	'm': 60,
+	'h': 60 * 60,
+	'd': 24 * 60 * 60,
+}
+
+// puncts are the operators and delimiters, longest first
+var puncts = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "(", ")", "[", "]", ",", ".", "+", "-"}
+
+func lex(src string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for {
+		for i < len(src) && isSpace(src[i]) {
+			i++
+		}
+		if i == len(src) {
+			return append(tokens, token{kind: tokenEOF, pos: i}), nil
+		}
+
+		start := i
+		c := src[i]
+		switch {
+		case isIdentStart(c):
+			for i < len(src) && isIdentPart(src[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: src[start:i], pos: start})
+
+		case c == '$':
+			i++
+			if i == len(src) || !isIdentStart(src[i]) {
+				return nil, &SyntaxError{Offset: start, Msg: "expected variable name"}
+			}
+			for i < len(src) && isIdentPart(src[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenVar, text: src[start:i], str: src[start+1 : i], pos: start})
+
+		case c == '"':
+			i++
+			for i < len(src) && src[i] != '"' {
+				if src[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(src) {
+				return nil, &SyntaxError{Offset: start, Msg: "unterminated string"}
+			}
+			i++
+			s, err := strconv.Unquote(src[start:i])
+			if err != nil {
+				return nil, &SyntaxError{Offset: start, Msg: "invalid string"}
+			}
+			tokens = append(tokens, token{kind: tokenString, text: src[start:i], str: s, pos: start})
+
+		case isDigit(c):
+			for i < len(src) && isDigit(src[i]) {
+				i++
+			}
+			if i+1 < len(src) && src[i] == '.' && isDigit(src[i+1]) {
+				i++
+				for i < len(src) && isDigit(src[i]) {
+					i++
+				}
+			}
+			n, err := strconv.ParseFloat(src[start:i], 64)
+			if err != nil {
+				return nil, &SyntaxError{Offset: start, Msg: "invalid number"}
+			}
+			if i < len(src) {
+				if unit, ok := units[src[i]]; ok && (i+1 == len(src) || !isIdentPart(src[i+1])) {
+					n *= unit
+					i++
+				}
+			}
+			if i < len(src) && isIdentPart(src[i]) {
+				return nil, &SyntaxError{Offset: start, Msg: "invalid number"}
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: src[start:i], num: n, pos: start})
+
+		default:
+			punct := ""
+			for _, p := range puncts {
+				if strings.HasPrefix(src[i:], p) {
+					punct = p
+					break
+				}
+			}
+			if punct == "" {
+				return nil, &SyntaxError{Offset: start, Msg: "unexpected character " + strconv.QuoteRune(rune(c))}
+			}
+			i += len(punct)
+			tokens = append(tokens, token{kind: tokenPunct, text: punct, pos: start})
+		}
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
@@ -0,0 +1,308 @@
+package policy
+
+type nodeKind int
+
+const (
+	nodeLiteral nodeKind = iota
+	nodeNow
+	nodeClaim
+	nodeVar
+	nodeList
+	nodeNot
+	nodeNeg
+	nodeAnd
+	nodeOr
+	nodeCompare
+	nodeArith
+)
+
+// node is an expression. src is the source text of the expression, used in
+// errors
+type node struct {
+	kind     nodeKind
+	op       string
+	src      string
+	value    interface{}
+	path     []string
+	children []*node
+}
+
+var compareOps = map[string]bool{
+	"==":       true,
+	"!=":       true,
+	"<":        true,
+	"<=":       true,
+	">":        true,
+	">=":       true,
+	"in":       true,
+	"contains": true,
+}
+
+var keywords = map[string]bool{
+	"true":     true,
+	"false":    true,
+	"null":     true,
+	"now":      true,
+	"in":       true,
+	"contains": true,
+}
+
+type parser struct {
+	src    string
+	tokens []token
+	pos    int
+}
+
+func parse(src string) (*node, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{src: src, tokens: tokens}
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.unexpected(t)
+	}
+	return n, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isPunct(s string) bool {
+	t := p.peek()
+	return t.kind == tokenPunct && t.text == s
+}
+
+func (p *parser) expect(s string) error {
+	if !p.isPunct(s) {
+		t := p.peek()
+		if t.kind == tokenEOF {
+			return &SyntaxError{Offset: t.pos, Msg: "expected " + s + " before end of policy"}
+		}
+		return &SyntaxError{Offset: t.pos, Msg: "expected " + s + " but found " + t.text}
+	}
+	p.next()
+	return nil
+}
+
+func (p *parser) unexpected(t token) error {
+	if t.kind == tokenEOF {
+		return &SyntaxError{Offset: t.pos, Msg: "unexpected end of policy"}
+	}
+	return &SyntaxError{Offset: t.pos, Msg: "unexpected " + t.text}
+}
+
+// end is the offset just after the last consumed token
+func (p *parser) end() int {
+	t := p.tokens[p.pos-1]
+	return t.pos + len(t.text)
+}
+
+func (p *parser) binary(kind nodeKind, op string, start int, l, r *node) *node {
+	return &node{kind: kind, op: op, src: p.src[start:p.end()], children: []*node{l, r}}
+}
+
+func (p *parser) parseOr() (*node, error) {
+	start := p.peek().pos
+	n, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isPunct("||") {
+		p.next()
+		r, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		n = p.binary(nodeOr, "||", start, n, r)
+	}
+	return n, nil
+}
+
+func (p *parser) parseAnd() (*node, error) {
+	start := p.peek().pos
+	n, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.isPunct("&&") {
+		p.next()
+		r, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		n = p.binary(nodeAnd, "&&", start, n, r)
+	}
+	return n, nil
+}
+
+func (p *parser) parseNot() (*node, error) {
+	start := p.peek().pos
+	if p.isPunct("!") {
+		p.next()
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &node{kind: nodeNot, op: "!", src: p.src[start:p.end()], children: []*node{x}}, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *parser) parseCompare() (*node, error) {
+	start := p.peek().pos
+	n, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	if (t.kind == tokenPunct || t.kind == tokenIdent) && compareOps[t.text] {
+		p.next()
+		r, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		n = p.binary(nodeCompare, t.text, start, n, r)
+	}
+	return n, nil
+}
+
+func (p *parser) parseSum() (*node, error) {
+	start := p.peek().pos
+	n, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isPunct("+") || p.isPunct("-") {
+		op := p.next().text
+		r, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		n = p.binary(nodeArith, op, start, n, r)
+	}
+	return n, nil
+}
+
+func (p *parser) parsePrimary() (*node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return &node{kind: nodeLiteral, src: t.text, value: t.str}, nil
+	case tokenNumber:
+		if i, ok := integer(t.text); ok {
+			return &node{kind: nodeLiteral, src: t.text, value: i}, nil
+		}
+		return &node{kind: nodeLiteral, src: t.text, value: t.num}, nil
+	case tokenVar:
+		path, err := p.parsePath([]string{t.str})
+		if err != nil {
+			return nil, err
+		}
+		return &node{kind: nodeVar, src: p.src[t.pos:p.end()], path: path}, nil
+	case tokenIdent:
+		switch t.text {
+		case "true":
+			return &node{kind: nodeLiteral, src: t.text, value: true}, nil
+		case "false":
+			return &node{kind: nodeLiteral, src: t.text, value: false}, nil
+		case "null":
+			return &node{kind: nodeLiteral, src: t.text, value: nil}, nil
+		case "now":
+			return &node{kind: nodeNow, src: t.text}, nil
+		case "claims":
+			path, err := p.parsePath(nil)
+			if err != nil {
+				return nil, err
+			}
+			return &node{kind: nodeClaim, src: p.src[t.pos:p.end()], path: path}, nil
+		}
+		if keywords[t.text] {
+			return nil, p.unexpected(t)
+		}
+		path, err := p.parsePath([]string{t.text})
+		if err != nil {
+			return nil, err
+		}
+		return &node{kind: nodeClaim, src: p.src[t.pos:p.end()], path: path}, nil
+	case tokenPunct:
+		switch t.text {
+		case "(":
+			n, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return n, p.expect(")")
+		case "[":
+			return p.parseList(t)
+		case "-":
+			x, err := p.parsePrimary()
+			if err != nil {
+				return nil, err
+			}
+			return &node{kind: nodeNeg, op: "-", src: p.src[t.pos:p.end()], children: []*node{x}}, nil
+		}
+	}
+	return nil, p.unexpected(t)
+}
+
+func (p *parser) parseList(open token) (*node, error) {
+	n := &node{kind: nodeList}
+	for !p.isPunct("]") {
+		item, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		n.children = append(n.children, item)
+		if !p.isPunct(",") {
+			break
+		}
+		p.next()
+	}
+	if err := p.expect("]"); err != nil {
+		return nil, err
+	}
+	n.src = p.src[open.pos:p.end()]
+	return n, nil
+}
+
+// parsePath parses the .name and ["name"] members following a claim or
+// variable
+func (p *parser) parsePath(path []string) ([]string, error) {
+	for {
+		switch {
+		case p.isPunct("."):
+			p.next()
+			t := p.next()
+			if t.kind != tokenIdent {
+				return nil, &SyntaxError{Offset: t.pos, Msg: "expected member name after ."}
+			}
+			path = append(path, t.text)
+		case p.isPunct("["):
+			p.next()
+			t := p.next()
+			if t.kind != tokenString {
+				return nil, &SyntaxError{Offset: t.pos, Msg: "expected quoted member name after ["}
+			}
+			path = append(path, t.str)
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+		default:
+			return path, nil
+		}
+	}
+}
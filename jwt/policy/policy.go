@@ -0,0 +1,152 @@
+// Package policy evaluates authorization policies against the claims of
+// verified tokens.
+//
+// A policy is an expression which must hold for the claims, e.g.
+//
+//	scope contains "orders:write" && tenant == $tenant && exp - now < 1h
+//
+// Claims are referenced by name, and members of objects with . or ["name"].
+// Claims whose names are not identifiers are referenced as claims["name"].
+// Variables supplied when evaluating are referenced as $name.
+//
+// The operators are, from lowest to highest precedence:
+//
+//	||                    either holds
+//	&&                    both hold
+//	!                     does not hold
+//	== != < <= > >=       comparison of numbers, strings, booleans and null
+//	in contains           membership of a list, or of a space-delimited string
+//	+ -                   arithmetic on numbers
+//
+// Literals are strings in double quotes, numbers, true, false, null and lists
+// in brackets. Numbers may have a s, m, h or d suffix, making them durations
+// in seconds, and now is the current time in seconds since the epoch, so time
+// claims can be compared with it. Integers are compared exactly; those beyond
+// 2^53, which a float64 can not represent, can only be compared with == and
+// != or be members of lists. A claim or variable on its own holds if it is
+// true.
+//
+// A clause which can not be evaluated, e.g. as a claim or variable is missing
+// or has the wrong type, does not hold, and neither does its negation.
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/KalleDK/go-jwt/jwt"
+)
+
+// ErrDenied is matched by errors.Is for an Error
+var ErrDenied = errors.New("policy denied")
+
+// Error is returned when a policy does not hold for the claims. Clause is the
+// innermost part of the policy which did not hold
+type Error struct {
+	Clause string
+	Reason string
+
+	// indeterminate is set if the clause could not be evaluated, rather than
+	// being false
+	indeterminate bool
+}
+
+func (e *Error) Error() string {
+	return "policy: " + strconv.Quote(e.Clause) + " does not hold: " + e.Reason
+}
+
+func (e *Error) Is(target error) bool { return target == ErrDenied }
+
+// SyntaxError is returned when a policy can not be compiled
+type SyntaxError struct {
+	Offset int
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return "policy: " + e.Msg + " at offset " + strconv.Itoa(e.Offset)
+}
+
+// Policy is a compiled policy. It is safe for concurrent use
+type Policy struct {
+	src  string
+	root *node
+
+	// Now returns the current time, time.Now is used if nil
+	Now func() time.Time
+}
+
+// Compile parses a policy
+func Compile(src string) (*Policy, error) {
+	root, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+	return &Policy{src: src, root: root}, nil
+}
+
+// MustCompile is Compile which panics if the policy can not be compiled
+func MustCompile(src string) *Policy {
+	p, err := Compile(src)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func (p *Policy) String() string {
+	return p.src
+}
+
+// Evaluate returns nil if the policy holds for the claims and variables, and
+// an Error naming the failing clause otherwise
+func (p *Policy) Evaluate(claims map[string]interface{}, vars map[string]interface{}) error {
+	now := time.Now
+	if p.Now != nil {
+		now = p.Now
+	}
+	e := env{claims: claims, vars: vars, now: now()}
+	return e.test(p.root)
+}
+
+// EvaluateJSON is Evaluate for claims encoded as a JSON object
+func (p *Policy) EvaluateJSON(payload []byte, vars map[string]interface{}) error {
+	claims, err := decodeClaims(payload)
+	if err != nil {
+		return err
+	}
+	return p.Evaluate(claims, vars)
+}
+
+// Validator returns a jwt.ValidatorFunc requiring the policy to hold for the
+// claims of tokens, e.g. for use with jwt.WithValidator. The variables are
+// typically taken from the request the token is presented with
+func (p *Policy) Validator(vars map[string]interface{}) jwt.ValidatorFunc {
+	return func(header *jwt.JOSEHeader, claims *jwt.RegisteredClaims, payload []byte) error {
+		return p.EvaluateJSON(payload, vars)
+	}
+}
+
+// WithPolicy requires the policy to hold for the claims of tokens, see
+// Policy.Validator
+func WithPolicy(p *Policy, vars map[string]interface{}) jwt.ParserOption {
+	return jwt.WithValidator(p.Validator(vars))
+}
+
+// decodeClaims decodes numbers as json.Number, so integers are compared exactly
+func decodeClaims(payload []byte) (map[string]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	var claims map[string]interface{}
+	if err := dec.Decode(&claims); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("policy: invalid data after claims")
+	}
+	return claims, nil
+}
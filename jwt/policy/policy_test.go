@@ -0,0 +1,193 @@
+package policy_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	_ "crypto/sha256"
+
+	_ "github.com/KalleDK/go-jwt/jwa/ecdsa"
+	"github.com/KalleDK/go-jwt/jwt"
+	"github.com/KalleDK/go-jwt/jwt/policy"
+)
+
+func TestEvaluate(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	claims := map[string]interface{}{
+		"sub":                       "1234567890",
+		"tenant":                    "acme",
+		"scope":                     "orders:read orders:write",
+		"roles":                     []interface{}{"admin", "billing"},
+		"email_verified":            true,
+		"exp":                       float64(1600000600),
+		"realm_access":              map[string]interface{}{"roles": []interface{}{"owner"}},
+		"https://example.com/level": float64(3),
+		"id":                        json.Number("9007199254740993"),
+		"count":                     int64(9007199254740993),
+	}
+	vars := map[string]interface{}{
+		"tenant":  "acme",
+		"groups":  []string{"billing", "support"},
+		"rounded": uint64(9007199254740992),
+	}
+
+	tests := []struct {
+		name       string
+		policy     string
+		claims     map[string]interface{}
+		wantClause string
+	}{
+		{name: "scope", policy: `scope contains "orders:write"`},
+		{name: "scope missing", policy: `scope contains "orders:delete"`, wantClause: `scope contains "orders:delete"`},
+		{name: "in list", policy: `"admin" in roles`},
+		{name: "in literal list", policy: `tenant in ["acme", "globex"]`},
+		{name: "variable", policy: `tenant == $tenant && sub != ""`},
+		{name: "variable list", policy: `"support" in $groups`},
+		{name: "nested", policy: `"owner" in realm_access.roles`},
+		{name: "quoted name", policy: `claims["https://example.com/level"] >= 3`},
+		{name: "boolean claim", policy: `email_verified`},
+		{name: "not", policy: `!("guest" in roles)`},
+		{name: "not fails", policy: `!("admin" in roles)`, wantClause: `!("admin" in roles)`},
+		{name: "time", policy: `exp > now && exp - now <= 10m`},
+		{name: "time fails", policy: `exp - now < 5m`, wantClause: `exp - now < 5m`},
+		{name: "or", policy: `"root" in roles || "billing" in roles`},
+		{name: "or fails", policy: `"root" in roles || tenant == "globex"`, wantClause: `"root" in roles || tenant == "globex"`},
+		{name: "and reports failing clause", policy: `tenant == "acme" && "root" in roles`, wantClause: `"root" in roles`},
+		{name: "missing claim", policy: `department == "sales"`, wantClause: `department == "sales"`},
+		{name: "unset variable", policy: `tenant == $org`, wantClause: `tenant == $org`},
+		{name: "type mismatch", policy: `tenant > 3`, wantClause: `tenant > 3`},
+		{name: "big integer", policy: `id == 9007199254740993 && count == id && id in [1, 9007199254740993]`},
+		{name: "big integer rounded", policy: `id == 9007199254740992`, wantClause: `id == 9007199254740992`},
+		{name: "big integer variable", policy: `count != $rounded`},
+		{name: "big integer ordered", policy: `id > 1`, wantClause: `id > 1`},
+		{name: "big integer arithmetic", policy: `id - 1 == 9007199254740992`, wantClause: `id - 1 == 9007199254740992`},
+		{name: "precedence", policy: `false && true || true`},
+		{name: "not unset variable", policy: `!(tenant != $org)`, wantClause: `tenant != $org`},
+		{name: "not type mismatch", policy: `!(exp < now)`, claims: map[string]interface{}{"exp": "tomorrow"}, wantClause: `exp < now`},
+		{name: "not missing claim", policy: `!!(department == "sales")`, wantClause: `department == "sales"`},
+		{name: "not or missing claim", policy: `!(department == "sales" || false)`, wantClause: `department == "sales" || false`},
+		{name: "not non-boolean claim", policy: `!tenant`, wantClause: `tenant`},
+		{name: "compare not missing claim", policy: `!(department == "sales") == true`, wantClause: `department == "sales"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := policy.Compile(tt.policy)
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			p.Now = func() time.Time { return now }
+
+			c := claims
+			if tt.claims != nil {
+				c = tt.claims
+			}
+			err = p.Evaluate(c, vars)
+			if tt.wantClause == "" {
+				if err != nil {
+					t.Errorf("Evaluate() error = %v", err)
+				}
+				return
+			}
+			var perr *policy.Error
+			if !errors.As(err, &perr) || !errors.Is(err, policy.ErrDenied) {
+				t.Fatalf("Evaluate() error = %v, want a policy.Error", err)
+			}
+			if perr.Clause != tt.wantClause {
+				t.Errorf("Evaluate() clause = %q, want %q", perr.Clause, tt.wantClause)
+			}
+		})
+	}
+}
+
+func TestEvaluateJSONIntegers(t *testing.T) {
+	payload := []byte(`{"id":9007199254740993,"level":3}`)
+	tests := []struct {
+		policy  string
+		wantErr bool
+	}{
+		{policy: `id == 9007199254740993 && level >= 3`},
+		{policy: `id == 9007199254740992`, wantErr: true},
+		{policy: `id != 9007199254740992`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			err := policy.MustCompile(tt.policy).EvaluateJSON(payload, nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("EvaluateJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []string{
+		``,
+		`tenant ==`,
+		`(tenant == "acme"`,
+		`"unterminated`,
+		`tenant == 'acme'`,
+		`roles[0]`,
+		`$`,
+		`10x`,
+		`in == 1`,
+		`a b`,
+	}
+	for _, src := range tests {
+		t.Run(src, func(t *testing.T) {
+			_, err := policy.Compile(src)
+			var serr *policy.SyntaxError
+			if !errors.As(err, &serr) {
+				t.Errorf("Compile() error = %v, want a SyntaxError", err)
+			}
+		})
+	}
+}
+
+func TestWithPolicy(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := jwt.Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: "1234567890"}}
+	if err := claims.Set("scope", "orders:read orders:write"); err != nil {
+		t.Fatal(err)
+	}
+	if err := claims.Set("tenant", "acme"); err != nil {
+		t.Fatal(err)
+	}
+	b, err := jwt.Marshal(rand.Reader, claims, jwt.ES256.NewSigner("key", key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier := jwt.ES256.NewVerifier("key", &key.PublicKey)
+
+	p := policy.MustCompile(`scope contains "orders:write" && tenant == $tenant`)
+
+	tests := []struct {
+		name    string
+		tenant  string
+		wantErr bool
+	}{
+		{name: "allowed", tenant: "acme"},
+		{name: "denied", tenant: "globex", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := jwt.NewParser(policy.WithPolicy(p, map[string]interface{}{"tenant": tt.tenant}))
+			var got jwt.Claims
+			_, err := parser.Unmarshal(b, &got, verifier)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parser.Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && (!errors.Is(err, policy.ErrDenied) || !strings.Contains(err.Error(), "tenant == $tenant")) {
+				t.Errorf("Parser.Unmarshal() error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+)
+
+var (
+	// ErrInvalidThreshold is returned when a quorum threshold can never be reached
+	ErrInvalidThreshold = errors.New("invalid quorum threshold")
+	// ErrQuorumNotReached is returned when too few authorities signed the token
+	ErrQuorumNotReached = errors.New("quorum not reached")
+)
+
+// UnmarshalQuorum verifies a JWS JSON Serialization against a set of
+// authorities and unmarshals the payload if at least threshold distinct
+// authorities signed it. Authorities are identified by their key ID, so
+// signatures are only checked against the authority with the kid of the
+// signature, and authorities without a key ID are never counted. The key IDs
+// of the authorities with a valid signature are returned in sorted order,
+// also when the quorum is not reached
+func UnmarshalQuorum(b []byte, payload interface{}, threshold int, authorities ...Verifier) ([]string, error) {
+	if threshold < 1 || threshold > len(authorities) {
+		return nil, ErrInvalidThreshold
+	}
+
+	payloadbuf, statuses, err := unmarshalJSON(b, NewVerifiers(true, authorities...))
+	if err != nil && !errors.Is(err, ErrInvalidSignature) {
+		return nil, err
+	}
+
+	signers := quorumSigners(statuses)
+	if len(signers) < threshold {
+		return signers, ErrQuorumNotReached
+	}
+
+	if err := json.Unmarshal(payloadbuf, payload); err != nil {
+		return signers, err
+	}
+
+	return signers, nil
+}
+
+func quorumSigners(statuses []SignatureStatus) []string {
+	seen := map[string]bool{}
+	signers := []string{}
+	for _, s := range statuses {
+		if !s.Valid() || s.KeyID == "" || seen[s.KeyID] {
+			continue
+		}
+		seen[s.KeyID] = true
+		signers = append(signers, s.KeyID)
+	}
+	sort.Strings(signers)
+	return signers
+}
@@ -0,0 +1,83 @@
+package jwt_test
+
+import (
+	"crypto/rand"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/KalleDK/go-jwt/jwt"
+)
+
+func TestUnmarshalQuorum(t *testing.T) {
+	alice := newTestKey(t, "alice")
+	bob := newTestKey(t, "bob")
+	carol := newTestKey(t, "carol")
+	mallory := newTestKey(t, "mallory")
+	impostor := newTestKey(t, "bob")
+
+	authorities := []jwt.Verifier{alice.verifier, bob.verifier, carol.verifier}
+	payload := testPayload{Subject: "approval", Name: "Transfer"}
+
+	tests := []struct {
+		name        string
+		signers     []jwt.Signer
+		threshold   int
+		wantSigners []string
+		wantErr     error
+	}{
+		{
+			name:        "two of three",
+			signers:     []jwt.Signer{alice.signer, carol.signer},
+			threshold:   2,
+			wantSigners: []string{"alice", "carol"},
+		},
+		{
+			name:        "duplicate signer counts once",
+			signers:     []jwt.Signer{alice.signer, alice.signer},
+			threshold:   2,
+			wantSigners: []string{"alice"},
+			wantErr:     jwt.ErrQuorumNotReached,
+		},
+		{
+			name:        "untrusted and impostor signers are not counted",
+			signers:     []jwt.Signer{alice.signer, mallory.signer, impostor.signer},
+			threshold:   2,
+			wantSigners: []string{"alice"},
+			wantErr:     jwt.ErrQuorumNotReached,
+		},
+		{
+			name:        "no valid signers",
+			signers:     []jwt.Signer{mallory.signer},
+			threshold:   1,
+			wantSigners: []string{},
+			wantErr:     jwt.ErrQuorumNotReached,
+		},
+		{
+			name:      "threshold above authorities",
+			signers:   []jwt.Signer{alice.signer},
+			threshold: 4,
+			wantErr:   jwt.ErrInvalidThreshold,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := jwt.MarshalJSON(rand.Reader, payload, tt.signers...)
+			if err != nil {
+				t.Fatalf("MarshalJSON() error = %v", err)
+			}
+
+			var got testPayload
+			gotSigners, err := jwt.UnmarshalQuorum(b, &got, tt.threshold, authorities...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UnmarshalQuorum() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(gotSigners, tt.wantSigners) {
+				t.Errorf("UnmarshalQuorum() signers = %v, want %v", gotSigners, tt.wantSigners)
+			}
+			if tt.wantErr == nil && got != payload {
+				t.Errorf("UnmarshalQuorum() payload = %v, want %v", got, payload)
+			}
+		})
+	}
+}
@@ -0,0 +1,281 @@
+package jwt
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrTokenReplayed is returned when a token ID has already been seen
+var ErrTokenReplayed = errors.New("token is replayed")
+
+// ReplayCache remembers the IDs of seen tokens until they expire. Add must
+// record the ID and report ErrTokenReplayed if it is already recorded as one
+// atomic operation, so concurrent uses of the same token are all rejected but
+// one
+type ReplayCache interface {
+	Add(id string, expires time.Time) error
+}
+
+// ReplayValidator rejects tokens with a jti claim already seen by Cache. The
+// ID is remembered until the exp claim plus Leeway, which should match the
+// leeway of the TimeValidator, or for TTL if the token has no exp claim.
+// Tokens without jti, and tokens without exp if TTL is zero, are rejected with
+// ErrMissingClaim. It should run after every other validator, so tokens which
+// are rejected are never recorded
+type ReplayValidator struct {
+	Cache  ReplayCache
+	TTL    time.Duration
+	Leeway time.Duration
+	// Now returns the current time, time.Now is used if nil
+	Now func() time.Time
+}
+
+func (v ReplayValidator) ValidateClaims(claims *RegisteredClaims) error {
+	if claims.ID == "" {
+		return &ClaimError{Claim: "jti", Err: ErrMissingClaim}
+	}
+
+	var expires time.Time
+	switch {
+	case claims.ExpiresAt != nil:
+		expires = claims.ExpiresAt.Add(v.Leeway)
+	case v.TTL > 0:
+		now := time.Now
+		if v.Now != nil {
+			now = v.Now
+		}
+		expires = now().Add(v.TTL)
+	default:
+		return &ClaimError{Claim: "exp", Err: ErrMissingClaim}
+	}
+
+	if err := v.Cache.Add(claims.ID, expires); err != nil {
+		return &ClaimError{Claim: "jti", Err: err}
+	}
+	return nil
+}
+
+// WithReplayCache rejects tokens already seen by cache, see ReplayValidator.
+// The cache is checked after every other validator, whatever the order of the
+// options
+func WithReplayCache(cache ReplayCache, ttl time.Duration) ParserOption {
+	return func(p *Parser) {
+		p.replay = func(header *JOSEHeader, claims *RegisteredClaims, payload []byte) error {
+			return ReplayValidator{Cache: cache, TTL: ttl, Leeway: p.leeway, Now: p.now}.ValidateClaims(claims)
+		}
+	}
+}
+
+// MemoryReplayCache is a ReplayCache holding the IDs in memory. Expired IDs
+// are dropped as new IDs are added
+type MemoryReplayCache struct {
+	mu      sync.Mutex
+	ids     map[string]time.Time
+	expires replayHeap
+	now     func() time.Time
+}
+
+// ReplayCacheOption configures a MemoryReplayCache or FileReplayCache
+type ReplayCacheOption func(c *MemoryReplayCache)
+
+// WithReplayCacheClock sets the function returning the current time, which
+// decides when IDs expire
+func WithReplayCacheClock(now func() time.Time) ReplayCacheOption {
+	return func(c *MemoryReplayCache) { c.now = now }
+}
+
+// NewMemoryReplayCache returns an empty MemoryReplayCache
+func NewMemoryReplayCache(opts ...ReplayCacheOption) *MemoryReplayCache {
+	c := &MemoryReplayCache{
+		ids: map[string]time.Time{},
+		now: time.Now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Add records id until expires. An id which has already expired is not
+// recorded, and nil is returned, as the token it belongs to is rejected by the
+// TimeValidator anyway
+func (c *MemoryReplayCache) Add(id string, expires time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.add(id, expires)
+}
+
+func (c *MemoryReplayCache) add(id string, expires time.Time) error {
+	now := c.now()
+	c.expire(now)
+
+	if _, ok := c.ids[id]; ok {
+		return ErrTokenReplayed
+	}
+	if !expires.After(now) {
+		return nil
+	}
+
+	c.ids[id] = expires
+	heap.Push(&c.expires, replayEntry{id: id, expires: expires})
+	return nil
+}
+
+// Len returns the number of IDs remembered
+func (c *MemoryReplayCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expire(c.now())
+	return len(c.ids)
+}
+
+func (c *MemoryReplayCache) expire(now time.Time) {
+	for len(c.expires) > 0 && !c.expires[0].expires.After(now) {
+		e := heap.Pop(&c.expires).(replayEntry)
+		delete(c.ids, e.id)
+	}
+}
+
+type replayEntry struct {
+	id      string
+	expires time.Time
+}
+
+type replayHeap []replayEntry
+
+func (h replayHeap) Len() int            { return len(h) }
+func (h replayHeap) Less(i, j int) bool  { return h[i].expires.Before(h[j].expires) }
+func (h replayHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *replayHeap) Push(x interface{}) { *h = append(*h, x.(replayEntry)) }
+func (h *replayHeap) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
+
+// FileReplayCache is a ReplayCache which also appends the IDs to a file, so
+// they survive restarts. The file is compacted when opened. It is only safe
+// for use by a single process
+type FileReplayCache struct {
+	memory *MemoryReplayCache
+	file   *os.File
+}
+
+type replayRecord struct {
+	ID      string `json:"jti"`
+	Expires int64  `json:"exp"`
+}
+
+// newReplayRecord rounds the expiry up to whole seconds, so IDs are never
+// forgotten early
+func newReplayRecord(id string, expires time.Time) replayRecord {
+	secs := expires.Unix()
+	if expires.Nanosecond() > 0 {
+		secs++
+	}
+	return replayRecord{ID: id, Expires: secs}
+}
+
+// OpenFileReplayCache opens or creates the file at path, loading the IDs
+// which have not yet expired
+func OpenFileReplayCache(path string, opts ...ReplayCacheOption) (*FileReplayCache, error) {
+	memory := NewMemoryReplayCache(opts...)
+
+	f, err := os.Open(path)
+	switch {
+	case err == nil:
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var r replayRecord
+			if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+				// A partially written last record is skipped
+				continue
+			}
+			memory.add(r.ID, time.Unix(r.Expires, 0))
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	case !os.IsNotExist(err):
+		return nil, err
+	}
+
+	if err := compactReplayFile(path, memory); err != nil {
+		return nil, err
+	}
+
+	f, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileReplayCache{memory: memory, file: f}, nil
+}
+
+func compactReplayFile(path string, memory *MemoryReplayCache) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	for id, expires := range memory.ids {
+		b, err := json.Marshal(newReplayRecord(id, expires))
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		w.Write(append(b, '\n'))
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Add records id until expires, in memory and in the file. Like
+// MemoryReplayCache.Add, an id which has already expired is not recorded
+func (c *FileReplayCache) Add(id string, expires time.Time) error {
+	c.memory.mu.Lock()
+	defer c.memory.mu.Unlock()
+
+	if err := c.memory.add(id, expires); err != nil {
+		return err
+	}
+	if _, ok := c.memory.ids[id]; !ok {
+		return nil
+	}
+
+	b, err := json.Marshal(newReplayRecord(id, expires))
+	if err != nil {
+		return err
+	}
+	if _, err := c.file.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	return c.file.Sync()
+}
+
+// Close closes the file
+func (c *FileReplayCache) Close() error {
+	return c.file.Close()
+}
@@ -0,0 +1,154 @@
+package jwt_test
+
+import (
+	"crypto/rand"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/KalleDK/go-jwt/jwt"
+)
+
+func TestMemoryReplayCache(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	cache := jwt.NewMemoryReplayCache(jwt.WithReplayCacheClock(func() time.Time { return now }))
+	expires := now.Add(time.Hour)
+
+	var accepted int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := cache.Add("reset-1", expires); err == nil {
+				atomic.AddInt32(&accepted, 1)
+			} else if err != jwt.ErrTokenReplayed {
+				t.Errorf("Add() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if accepted != 1 {
+		t.Errorf("Add() accepted %v times, want 1", accepted)
+	}
+
+	if err := cache.Add("expired", now.Add(-time.Second)); err != nil {
+		t.Errorf("Add() error = %v", err)
+	}
+	if err := cache.Add("short", now.Add(10*time.Millisecond)); err != nil {
+		t.Errorf("Add() error = %v", err)
+	}
+	if n := cache.Len(); n != 2 {
+		t.Errorf("Len() = %v, want 2", n)
+	}
+	now = now.Add(20 * time.Millisecond)
+	if n := cache.Len(); n != 1 {
+		t.Errorf("Len() = %v, want 1", n)
+	}
+	if err := cache.Add("short", expires); err != nil {
+		t.Errorf("Add() of expired ID error = %v", err)
+	}
+}
+
+func TestFileReplayCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "replay.jsonl")
+	now := time.Unix(1600000000, 0)
+	clock := jwt.WithReplayCacheClock(func() time.Time { return now })
+	expires := now.Add(time.Hour)
+
+	cache, err := jwt.OpenFileReplayCache(path, clock)
+	if err != nil {
+		t.Fatalf("OpenFileReplayCache() error = %v", err)
+	}
+	if err := cache.Add("reset-1", expires); err != nil {
+		t.Errorf("Add() error = %v", err)
+	}
+	if err := cache.Add("stale", now.Add(time.Second)); err != nil {
+		t.Errorf("Add() error = %v", err)
+	}
+	if err := cache.Add("expired", now.Add(-time.Second)); err != nil {
+		t.Errorf("Add() error = %v", err)
+	}
+	if err := cache.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if b, err := ioutil.ReadFile(path); err != nil || strings.Contains(string(b), `"expired"`) {
+		t.Errorf("file = %s, %v, want no expired ID", b, err)
+	}
+
+	now = now.Add(2 * time.Second)
+
+	cache, err = jwt.OpenFileReplayCache(path, clock)
+	if err != nil {
+		t.Fatalf("OpenFileReplayCache() error = %v", err)
+	}
+	defer cache.Close()
+	if err := cache.Add("reset-1", expires); err != jwt.ErrTokenReplayed {
+		t.Errorf("Add() after reopen error = %v, want %v", err, jwt.ErrTokenReplayed)
+	}
+	if err := cache.Add("stale", expires); err != nil {
+		t.Errorf("Add() of expired ID error = %v", err)
+	}
+}
+
+func TestReplayValidator(t *testing.T) {
+	key := newTestKey(t, "key")
+	now := time.Now()
+
+	once, err := jwt.Marshal(rand.Reader, jwt.RegisteredClaims{ID: "once", ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute))}, key.signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	noID, err := jwt.Marshal(rand.Reader, jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute))}, key.signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache := jwt.NewMemoryReplayCache()
+	p := jwt.NewParser(jwt.WithReplayCache(cache, 0))
+
+	tests := []struct {
+		name    string
+		token   []byte
+		wantErr error
+	}{
+		{name: "first use", token: once},
+		{name: "replay", token: once, wantErr: jwt.ErrTokenReplayed},
+		{name: "missing jti", token: noID, wantErr: jwt.ErrMissingClaim},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var claims jwt.RegisteredClaims
+			if _, err := p.Unmarshal(tt.token, &claims, key.verifier); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Parser.Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("rejected by a later validator", func(t *testing.T) {
+		cache := jwt.NewMemoryReplayCache()
+		var claims jwt.RegisteredClaims
+		p := jwt.NewParser(jwt.WithReplayCache(cache, 0), jwt.WithIssuer("issuer"))
+		if _, err := p.Unmarshal(once, &claims, key.verifier); !errors.Is(err, jwt.ErrInvalidIssuer) {
+			t.Fatalf("Parser.Unmarshal() error = %v, want %v", err, jwt.ErrInvalidIssuer)
+		}
+		if n := cache.Len(); n != 0 {
+			t.Errorf("Len() = %v, want a rejected token not to be recorded", n)
+		}
+	})
+
+	var claims jwt.RegisteredClaims
+	replay := jwt.ReplayValidator{Cache: jwt.NewMemoryReplayCache()}
+	if _, err := jwt.Unmarshal(once, &claims, key.verifier, jwt.TimeValidator{}, replay); err != nil {
+		t.Errorf("Unmarshal() error = %v", err)
+	}
+	if _, err := jwt.Unmarshal(once, &claims, key.verifier, jwt.TimeValidator{}, replay); !errors.Is(err, jwt.ErrTokenReplayed) {
+		t.Errorf("Unmarshal() error = %v, want %v", err, jwt.ErrTokenReplayed)
+	}
+}
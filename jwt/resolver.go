@@ -0,0 +1,24 @@
+package jwt
+
+// Resolver selects the verifiers of a token from its header, e.g. from keys
+// or certificates carried in or referenced by the header
+type Resolver interface {
+	Resolve(header *JOSEHeader) (Verifiers, error)
+}
+
+// ResolverFunc is a function used as a Resolver
+type ResolverFunc func(header *JOSEHeader) (Verifiers, error)
+
+func (f ResolverFunc) Resolve(header *JOSEHeader) (Verifiers, error) {
+	return f(header)
+}
+
+// UnmarshalResolved is Unmarshal with the verifiers resolved from the header
+// of the token, which is unmarshaled into header. The header and claims are
+// validated as by a Parser configured by the options before and after the
+// verifiers are resolved, so only the "JWT" type is accepted unless WithTypes
+// is given. As the resolver builds the verifiers for the alg header, use
+// WithAlgorithms to restrict it to the algorithms of the expected keys
+func UnmarshalResolved(b []byte, payload interface{}, header *JOSEHeader, resolver Resolver, opts ...ParserOption) (string, error) {
+	return NewParser(opts...).UnmarshalResolved(b, payload, header, resolver)
+}
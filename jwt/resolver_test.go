@@ -0,0 +1,51 @@
+package jwt_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/KalleDK/go-jwt/jwt"
+)
+
+func TestUnmarshalResolved(t *testing.T) {
+	key := newTestKey(t, "key")
+
+	tests := []struct {
+		name         string
+		header       string
+		opts         []jwt.ParserOption
+		wantErr      error
+		wantResolved bool
+	}{
+		{name: "valid", header: `{"alg":"ES256","kid":"key","typ":"JWT"}`, opts: []jwt.ParserOption{jwt.WithAlgorithms(jwt.ES256)}, wantResolved: true},
+		{name: "JWT by default", header: `{"alg":"ES256","kid":"key","typ":"dpop+jwt"}`, wantErr: jwt.ErrInvalidHeaderType},
+		{name: "expected type", header: `{"alg":"ES256","kid":"key","typ":"dpop+jwt"}`, opts: []jwt.ParserOption{jwt.WithTypes("dpop+jwt")}, wantResolved: true},
+		{name: "unexpected type", header: `{"alg":"ES256","kid":"key","typ":"JWT"}`, opts: []jwt.ParserOption{jwt.WithTypes("dpop+jwt")}, wantErr: jwt.ErrInvalidHeaderType},
+		{name: "algorithm not allowed", header: `{"alg":"ES256","kid":"key","typ":"JWT"}`, opts: []jwt.ParserOption{jwt.WithAlgorithms(jwt.RS256)}, wantErr: jwt.ErrUnsupportedAlgorithm},
+		{name: "claims validated", header: `{"alg":"ES256","kid":"key","typ":"JWT"}`, opts: []jwt.ParserOption{jwt.WithRequiredClaims("exp")}, wantErr: jwt.ErrMissingClaim, wantResolved: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := signCompact(t, key.signer, tt.header, `{"sub":"sub"}`)
+
+			resolved := false
+			resolver := jwt.ResolverFunc(func(header *jwt.JOSEHeader) (jwt.Verifiers, error) {
+				resolved = true
+				return key.verifier, nil
+			})
+
+			var header jwt.JOSEHeader
+			var payload testPayload
+			kid, err := jwt.UnmarshalResolved(b, &payload, &header, resolver, tt.opts...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UnmarshalResolved() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if resolved != tt.wantResolved {
+				t.Errorf("UnmarshalResolved() resolved = %v, want %v", resolved, tt.wantResolved)
+			}
+			if err == nil && (kid != "key" || payload.Subject != "sub" || header.Kid() != "key") {
+				t.Errorf("UnmarshalResolved() kid = %v, payload = %v, header = %v", kid, payload, header)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package jwt_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	_ "crypto/sha512"
+
+	"github.com/KalleDK/go-jwt/jwt"
+)
+
+func TestRSA(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		alg  jwt.Algorithm
+		name string
+	}{
+		{alg: jwt.RS256, name: "RS256"},
+		{alg: jwt.RS384, name: "RS384"},
+		{alg: jwt.RS512, name: "RS512"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.alg.String(); got != tt.name {
+				t.Errorf("String() = %v, want %v", got, tt.name)
+			}
+
+			b, err := jwt.Marshal(rand.Reader, testPayload{Subject: "1234567890"}, tt.alg.NewSigner("rsa", key))
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+
+			token, err := jwt.Parse(b, tt.alg.NewVerifier("rsa", &key.PublicKey))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !token.Verified || token.Algorithm != tt.alg || token.KeyID != "rsa" {
+				t.Errorf("Parse() = %+v", token)
+			}
+		})
+	}
+}
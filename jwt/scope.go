@@ -0,0 +1,211 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ErrInsufficientScope is matched by errors.Is for a ScopeError
+var ErrInsufficientScope = errors.New("insufficient scope")
+
+// Scopes is a scope claim, which RFC 8693 4.2 encodes as a space-delimited
+// string. Arrays, as used by some issuers in scp, are also accepted. Scopes is
+// marshaled as a space-delimited string
+type Scopes []string
+
+func (s Scopes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strings.Join(s, " "))
+}
+
+func (s *Scopes) UnmarshalJSON(b []byte) error {
+	// null leaves the scopes unchanged, as encoding/json does for slices
+	if string(b) == "null" {
+		return nil
+	}
+
+	var str string
+	if err := json.Unmarshal(b, &str); err == nil {
+		*s = Scopes(strings.Fields(str))
+		return nil
+	}
+
+	var l []string
+	if err := json.Unmarshal(b, &l); err != nil {
+		return err
+	}
+	*s = Scopes(l)
+	return nil
+}
+
+// HasAll reports if every one of the scopes is granted, see Match
+func (s Scopes) HasAll(scopes ...string) bool {
+	return hasAll(s, scopes)
+}
+
+// HasAny reports if at least one of the scopes is granted, see Match
+func (s Scopes) HasAny(scopes ...string) bool {
+	return hasAny(s, scopes)
+}
+
+// Roles is a roles or groups claim (RFC 9068 2.2.3.1), an array of strings.
+// A single string is also accepted
+type Roles []string
+
+func (r *Roles) UnmarshalJSON(b []byte) error {
+	// null leaves the roles unchanged, as encoding/json does for slices
+	if string(b) == "null" {
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*r = Roles{s}
+		return nil
+	}
+
+	var l []string
+	if err := json.Unmarshal(b, &l); err != nil {
+		return err
+	}
+	*r = Roles(l)
+	return nil
+}
+
+// HasAll reports if every one of the roles is granted, see Match
+func (r Roles) HasAll(roles ...string) bool {
+	return hasAll(r, roles)
+}
+
+// HasAny reports if at least one of the roles is granted, see Match
+func (r Roles) HasAny(roles ...string) bool {
+	return hasAny(r, roles)
+}
+
+// AccessClaims are the claims of access tokens granting scopes, roles and
+// groups
+type AccessClaims struct {
+	Scope  Scopes `json:"scope,omitempty"`
+	Scp    Scopes `json:"scp,omitempty"`
+	Roles  Roles  `json:"roles,omitempty"`
+	Groups Roles  `json:"groups,omitempty"`
+}
+
+// Scopes returns the scopes of both the scope and the scp claim
+func (c *AccessClaims) Scopes() Scopes {
+	if len(c.Scp) == 0 {
+		return c.Scope
+	}
+	return append(append(Scopes{}, c.Scope...), c.Scp...)
+}
+
+// Match reports if the granted value grants required. A granted value ending
+// in * grants every value it is a prefix of, ending at a separator, so
+// "orders:*" grants "orders:read" and "orders:items:read" but not "ordersx".
+// A granted value of * grants everything
+func Match(granted, required string) bool {
+	if granted == required || granted == "*" {
+		return true
+	}
+	if !strings.HasSuffix(granted, "*") {
+		return false
+	}
+	prefix := granted[:len(granted)-1]
+	if !strings.HasPrefix(required, prefix) || len(required) == len(prefix) {
+		return false
+	}
+	// The wildcard must follow a separator, "orders*" is no wildcard
+	return strings.ContainsAny(prefix[len(prefix)-1:], ":/.")
+}
+
+func grants(granted []string, required string) bool {
+	for _, g := range granted {
+		if Match(g, required) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAll(granted []string, required []string) bool {
+	for _, r := range required {
+		if !grants(granted, r) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasAny(granted []string, required []string) bool {
+	for _, r := range required {
+		if grants(granted, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// ScopeError is returned when the scopes, roles or groups of a token do not
+// grant the required ones
+type ScopeError struct {
+	// Claim is "scope", "roles" or "groups"
+	Claim    string
+	Required []string
+	Granted  []string
+	Any      bool
+}
+
+func (e *ScopeError) Error() string {
+	want := "all of"
+	if e.Any {
+		want = "one of"
+	}
+	return "insufficient " + e.Claim + " [" + strings.Join(e.Granted, ", ") + "], required " + want + " [" + strings.Join(e.Required, ", ") + "]"
+}
+
+func (e *ScopeError) Is(target error) bool { return target == ErrInsufficientScope }
+
+// WithScopes requires the scope or scp claim to grant all of the scopes
+func WithScopes(scopes ...string) ParserOption {
+	return withAccess("scope", scopes, false, (*AccessClaims).Scopes)
+}
+
+// WithAnyScope requires the scope or scp claim to grant at least one of the scopes
+func WithAnyScope(scopes ...string) ParserOption {
+	return withAccess("scope", scopes, true, (*AccessClaims).Scopes)
+}
+
+// WithRoles requires the roles claim to grant all of the roles
+func WithRoles(roles ...string) ParserOption {
+	return withAccess("roles", roles, false, func(c *AccessClaims) Scopes { return Scopes(c.Roles) })
+}
+
+// WithAnyRole requires the roles claim to grant at least one of the roles
+func WithAnyRole(roles ...string) ParserOption {
+	return withAccess("roles", roles, true, func(c *AccessClaims) Scopes { return Scopes(c.Roles) })
+}
+
+// WithGroups requires the groups claim to grant all of the groups
+func WithGroups(groups ...string) ParserOption {
+	return withAccess("groups", groups, false, func(c *AccessClaims) Scopes { return Scopes(c.Groups) })
+}
+
+// WithAnyGroup requires the groups claim to grant at least one of the groups
+func WithAnyGroup(groups ...string) ParserOption {
+	return withAccess("groups", groups, true, func(c *AccessClaims) Scopes { return Scopes(c.Groups) })
+}
+
+func withAccess(claim string, required []string, anyOf bool, granted func(c *AccessClaims) Scopes) ParserOption {
+	return WithValidator(func(header *JOSEHeader, claims *RegisteredClaims, payload []byte) error {
+		var access AccessClaims
+		if err := json.Unmarshal(payload, &access); err != nil {
+			return err
+		}
+
+		g := granted(&access)
+		if anyOf && !g.HasAny(required...) || !anyOf && !g.HasAll(required...) {
+			return &ClaimError{Claim: claim, Err: &ScopeError{Claim: claim, Required: required, Granted: g, Any: anyOf}}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,105 @@
+package jwt_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/KalleDK/go-jwt/jwt"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		granted  string
+		required string
+		want     bool
+	}{
+		{granted: "orders:read", required: "orders:read", want: true},
+		{granted: "orders:read", required: "orders:write"},
+		{granted: "orders:*", required: "orders:write", want: true},
+		{granted: "orders:*", required: "orders:items:read", want: true},
+		{granted: "orders:*", required: "orders:"},
+		{granted: "orders:*", required: "ordersx:read"},
+		{granted: "orders*", required: "ordersx"},
+		{granted: "api/*", required: "api/orders", want: true},
+		{granted: "*", required: "anything", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.granted+" "+tt.required, func(t *testing.T) {
+			if got := jwt.Match(tt.granted, tt.required); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAccessClaims(t *testing.T) {
+	var c jwt.AccessClaims
+	if err := json.Unmarshal([]byte(`{"scope":"openid  orders:read","scp":["orders:*"],"roles":"admin","groups":["staff","billing"]}`), &c); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := (jwt.Scopes{"openid", "orders:read", "orders:*"}); !reflect.DeepEqual(c.Scopes(), want) {
+		t.Errorf("Scopes() = %v, want %v", c.Scopes(), want)
+	}
+	if !c.Scopes().HasAll("openid", "orders:write") || c.Scopes().HasAll("openid", "users:read") {
+		t.Errorf("Scopes().HasAll() mismatch")
+	}
+	if !c.Roles.HasAny("owner", "admin") || c.Groups.HasAny("admin") {
+		t.Errorf("HasAny() mismatch")
+	}
+
+	b, err := json.Marshal(jwt.AccessClaims{Scope: jwt.Scopes{"openid", "profile"}, Roles: jwt.Roles{"admin"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"scope":"openid profile","roles":["admin"]}`; string(b) != want {
+		t.Errorf("Marshal() = %s, want %s", b, want)
+	}
+}
+
+func TestAccessClaimsNull(t *testing.T) {
+	var c jwt.AccessClaims
+	if err := json.Unmarshal([]byte(`{"scope":null,"scp":null,"roles":null,"groups":null}`), &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Scope != nil || c.Scp != nil || c.Roles != nil || c.Groups != nil {
+		t.Errorf("Unmarshal() = %#v, want no scopes, roles or groups", c)
+	}
+	if c.Roles.HasAny("") || c.Groups.HasAll("") {
+		t.Errorf("HasAny() granted a role for null")
+	}
+}
+
+func TestScopeOptions(t *testing.T) {
+	key := newTestKey(t, "key")
+	b := signCompact(t, key.signer, `{"alg":"ES256","typ":"JWT"}`, `{"sub":"1234567890","scope":"orders:* openid","roles":["admin"],"groups":["staff"]}`)
+
+	tests := []struct {
+		name    string
+		opt     jwt.ParserOption
+		wantErr bool
+	}{
+		{name: "scopes", opt: jwt.WithScopes("openid", "orders:write")},
+		{name: "missing scope", opt: jwt.WithScopes("openid", "users:read"), wantErr: true},
+		{name: "any scope", opt: jwt.WithAnyScope("users:read", "openid")},
+		{name: "roles", opt: jwt.WithRoles("admin")},
+		{name: "any role", opt: jwt.WithAnyRole("owner"), wantErr: true},
+		{name: "groups", opt: jwt.WithGroups("staff", "billing"), wantErr: true},
+		{name: "any group", opt: jwt.WithAnyGroup("staff", "billing")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var claims jwt.Claims
+			_, err := jwt.NewParser(tt.opt).Unmarshal(b, &claims, key.verifier)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parser.Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var serr *jwt.ScopeError
+			if err != nil && (!errors.Is(err, jwt.ErrInsufficientScope) || !errors.As(err, &serr)) {
+				t.Errorf("Parser.Unmarshal() error = %v, want a ScopeError", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package jwt
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"hash"
+	"io"
+	"io/ioutil"
+)
+
+// ErrStreamingUnsupported is returned when a signer, verifier or algorithm
+// can not sign or verify a precomputed digest
+var ErrStreamingUnsupported = errors.New("streaming is unsupported")
+
+const (
+	// maxStreamHeaderSize limits the encoded header read by UnmarshalStream
+	maxStreamHeaderSize = 64 << 10
+	// maxStreamSignatureSize limits the encoded signature read by UnmarshalStream
+	maxStreamSignatureSize = 8 << 10
+)
+
+// DigestSigner is a Signer that can sign a digest of the signing input
+// computed by the caller
+type DigestSigner interface {
+	Signer
+	SignDigest(rand io.Reader, digest []byte) (signature []byte, err error)
+}
+
+// DigestVerifiers are Verifiers that can verify a signature over a digest of
+// the signing input computed by the caller
+type DigestVerifiers interface {
+	Verifiers
+	VerifyDigest(a Algorithm, kidSuggest string, digest, signature []byte) (kidUsed string, err error)
+}
+
+// MarshalStream writes a compact token to w with the bytes read from payload
+// as the payload. The payload is encoded and hashed as it is read, so it is
+// never held in memory
+func MarshalStream(rand io.Reader, w io.Writer, payload io.Reader, signer Signer) error {
+	header := header{
+		Type: "JWT",
+	}
+	return MarshalStreamWithHeader(rand, w, payload, &header, signer)
+}
+
+func MarshalStreamWithHeader(rand io.Reader, w io.Writer, payload io.Reader, header Header, signer Signer) error {
+	ds, ok := signer.(DigestSigner)
+	if !ok {
+		return ErrStreamingUnsupported
+	}
+
+	hasher, err := newStreamHash(signer.Algorithm())
+	if err != nil {
+		return err
+	}
+
+	header.SetAlg(signer.Algorithm())
+	header.SetKid(signer.KeyID())
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+
+	signed := io.MultiWriter(w, hasher)
+
+	headerSegment := make([]byte, encodedSegmentLength(len(headerJSON))+1)
+	encodeSegment(headerSegment, headerJSON)
+	headerSegment[len(headerSegment)-1] = '.'
+	if _, err := signed.Write(headerSegment); err != nil {
+		return err
+	}
+
+	enc := base64.NewEncoder(base64.RawURLEncoding, signed)
+	if _, err := io.Copy(enc, payload); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+
+	signature, err := ds.SignDigest(rand, hasher.Sum(nil))
+	if err != nil {
+		return err
+	}
+
+	signatureSegment := make([]byte, 1+encodedSegmentLength(len(signature)))
+	signatureSegment[0] = '.'
+	encodeSegment(signatureSegment[1:], signature)
+	_, err = w.Write(signatureSegment)
+	return err
+}
+
+// UnmarshalStream verifies a compact token read from r and writes the decoded
+// payload to payload. The payload is written as it is read, before the
+// signature is verified, so anything written must be discarded if an error is
+// returned
+func UnmarshalStream(r io.Reader, payload io.Writer, verifiers Verifiers) (string, error) {
+	var header header
+	return UnmarshalStreamWithHeader(r, payload, &header, verifiers)
+}
+
+func UnmarshalStreamWithHeader(r io.Reader, payload io.Writer, header Header, verifiers Verifiers) (string, error) {
+	dv, ok := verifiers.(DigestVerifiers)
+	if !ok {
+		return "", ErrStreamingUnsupported
+	}
+
+	br := bufio.NewReader(r)
+
+	headerSegment, err := readHeaderSegment(br)
+	if err != nil {
+		return "", err
+	}
+
+	if err := unmarshalHeader(headerSegment, header); err != nil {
+		return "", err
+	}
+
+	if err := checkAlgorithm(header); err != nil {
+		return "", err
+	}
+
+	hasher, err := newStreamHash(header.Alg())
+	if err != nil {
+		return "", err
+	}
+	hasher.Write(headerSegment)
+	hasher.Write([]byte{'.'})
+
+	dec := &segmentDecoder{w: payload}
+	if err := copySegment(io.MultiWriter(hasher, dec), br); err != nil {
+		return "", err
+	}
+	if err := dec.Close(); err != nil {
+		return "", err
+	}
+
+	signatureSegment, err := ioutil.ReadAll(io.LimitReader(br, maxStreamSignatureSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(signatureSegment) > maxStreamSignatureSize {
+		return "", ErrMalformedToken
+	}
+
+	signature, err := decodeSegment(signatureSegment)
+	if err != nil {
+		return "", malformed("signature", err)
+	}
+
+	return dv.VerifyDigest(header.Alg(), header.Kid(), hasher.Sum(nil), signature)
+}
+
+func newStreamHash(a Algorithm) (hash.Hash, error) {
+	h := a.Hash()
+	if h == 0 || !h.Available() {
+		return nil, ErrStreamingUnsupported
+	}
+	return h.New(), nil
+}
+
+// readHeaderSegment reads the header segment and the following dot
+func readHeaderSegment(br *bufio.Reader) ([]byte, error) {
+	var segment []byte
+	for {
+		chunk, err := br.ReadSlice('.')
+		if len(segment)+len(chunk) > maxStreamHeaderSize+1 {
+			return nil, ErrMalformedToken
+		}
+		segment = append(segment, chunk...)
+		switch err {
+		case nil:
+			return segment[:len(segment)-1], nil
+		case bufio.ErrBufferFull:
+			continue
+		case io.EOF:
+			return nil, ErrMalformedToken
+		default:
+			return nil, err
+		}
+	}
+}
+
+// copySegment copies the segment read from br to w and consumes the
+// following dot
+func copySegment(w io.Writer, br *bufio.Reader) error {
+	for {
+		chunk, err := br.ReadSlice('.')
+		switch err {
+		case nil:
+			_, err = w.Write(chunk[:len(chunk)-1])
+			return err
+		case bufio.ErrBufferFull:
+			if _, err := w.Write(chunk); err != nil {
+				return err
+			}
+		case io.EOF:
+			return ErrMalformedToken
+		default:
+			return err
+		}
+	}
+}
+
+// segmentDecoder decodes a base64url encoded segment written to it in
+// arbitrary chunks
+type segmentDecoder struct {
+	w    io.Writer
+	rest []byte
+}
+
+func (d *segmentDecoder) Write(p []byte) (int, error) {
+	data := append(d.rest, p...)
+	n := len(data) / 4 * 4
+	if err := d.flush(data[:n]); err != nil {
+		return 0, err
+	}
+	d.rest = append(d.rest[:0], data[n:]...)
+	return len(p), nil
+}
+
+func (d *segmentDecoder) Close() error {
+	return d.flush(d.rest)
+}
+
+func (d *segmentDecoder) flush(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	b, err := decodeSegment(data)
+	if err != nil {
+		return err
+	}
+	_, err = d.w.Write(b)
+	return err
+}
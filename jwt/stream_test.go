@@ -0,0 +1,99 @@
+package jwt_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/KalleDK/go-jwt/jwt"
+)
+
+func TestMarshalStream(t *testing.T) {
+	key := newTestKey(t, "manifest")
+	manifest := []byte(`{"sub":"` + strings.Repeat("a", 1<<20) + `","name":"manifest"}`)
+
+	var token bytes.Buffer
+	if err := jwt.MarshalStream(rand.Reader, &token, bytes.NewReader(manifest), key.signer); err != nil {
+		t.Fatalf("MarshalStream() error = %v", err)
+	}
+
+	var got testPayload
+	if _, err := jwt.Unmarshal(token.Bytes(), &got, key.verifier); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if got.Name != "manifest" {
+		t.Errorf("Unmarshal() payload name = %v, want manifest", got.Name)
+	}
+
+	compact, err := jwt.Marshal(rand.Reader, testPayload{Subject: "small"}, key.signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := append([]byte{}, token.Bytes()...)
+	tampered[len(tampered)/2] ^= 1
+
+	tests := []struct {
+		name    string
+		token   []byte
+		want    []byte
+		wantErr bool
+	}{
+		{name: "streamed", token: token.Bytes(), want: manifest},
+		{name: "compact", token: compact, want: []byte(`{"sub":"small","name":""}`)},
+		{name: "tampered", token: tampered, wantErr: true},
+		{name: "missing signature", token: token.Bytes()[:bytes.LastIndexByte(token.Bytes(), '.')], wantErr: true},
+		{name: "no dots", token: []byte(strings.Repeat("a", 1<<17)), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var payload bytes.Buffer
+			kid, err := jwt.UnmarshalStream(bytes.NewReader(tt.token), &payload, jwt.NewVerifiers(false, key.verifier))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnmarshalStream() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if kid != "manifest" {
+				t.Errorf("UnmarshalStream() kid = %v, want manifest", kid)
+			}
+			if !bytes.Equal(payload.Bytes(), tt.want) {
+				t.Errorf("UnmarshalStream() payload length = %v, want %v", payload.Len(), len(tt.want))
+			}
+		})
+	}
+}
+
+func TestUnmarshalStreamSignatureError(t *testing.T) {
+	key := newTestKey(t, "manifest")
+	other := newTestKey(t, "manifest")
+
+	var token bytes.Buffer
+	if err := jwt.MarshalStream(rand.Reader, &token, strings.NewReader(`{"sub":"manifest"}`), key.signer); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		verifiers jwt.Verifiers
+	}{
+		{name: "verifier", verifiers: other.verifier},
+		{name: "key id must match", verifiers: jwt.NewVerifiers(true, other.verifier)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := jwt.UnmarshalStream(bytes.NewReader(token.Bytes()), ioutil.Discard, tt.verifiers)
+			if !errors.Is(err, jwt.ErrInvalidSignature) {
+				t.Fatalf("UnmarshalStream() error = %v, want %v", err, jwt.ErrInvalidSignature)
+			}
+			var sigErr *jwt.SignatureError
+			if !errors.As(err, &sigErr) || sigErr.KeyID != "manifest" {
+				t.Errorf("UnmarshalStream() error = %#v, want SignatureError for manifest", err)
+			}
+		})
+	}
+}
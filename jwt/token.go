@@ -29,12 +29,19 @@ func newTokenBuffer(headerSize int, payloadSize int, signatureSize int) tokenBuf
 }
 
 func parseTokenBuffer(b []byte) (tokenBuffer, error) {
-	idx1 := bytes.Index(b[:], []byte{'.'})
-	idx2 := bytes.Index(b[idx1+1:], []byte{'.'}) + idx1 + 1
+	idx1 := bytes.IndexByte(b, '.')
+	if idx1 < 0 {
+		return tokenBuffer{}, &MalformedError{Offset: len(b), Err: errMissingDot}
+	}
+	idx2 := bytes.IndexByte(b[idx1+1:], '.')
+	if idx2 < 0 {
+		return tokenBuffer{}, &MalformedError{Offset: len(b), Err: errMissingDot}
+	}
+	idx2 += idx1 + 1
 
 	// Verify no more dots
-	if bytes.Index(b[idx2+1:], []byte{'.'}) > 0 {
-		return tokenBuffer{}, ErrMalformedToken
+	if idx3 := bytes.IndexByte(b[idx2+1:], '.'); idx3 >= 0 {
+		return tokenBuffer{}, &MalformedError{Offset: idx2 + 1 + idx3, Err: errUnexpectedDot}
 	}
 
 	return tokenBuffer{
@@ -46,3 +53,98 @@ func parseTokenBuffer(b []byte) (tokenBuffer, error) {
 	}, nil
 
 }
+
+// detached returns the token with an empty payload segment
+func (t tokenBuffer) detached() []byte {
+	encHS := len(t.headerSlice)
+	buffer := make([]byte, encHS+2+len(t.signatureSlice))
+	copy(buffer, t.headerSlice)
+	buffer[encHS] = '.'
+	buffer[encHS+1] = '.'
+	copy(buffer[encHS+2:], t.signatureSlice)
+	return buffer
+}
+
+// attach returns a copy of a detached token with room for a payload segment
+// of n bytes
+func (t tokenBuffer) attach(n int) tokenBuffer {
+	encHS := len(t.headerSlice)
+	encHPS := encHS + 1 + n
+	buffer := make([]byte, encHPS+1+len(t.signatureSlice))
+	copy(buffer, t.headerSlice)
+	buffer[encHS] = '.'
+	buffer[encHPS] = '.'
+	copy(buffer[encHPS+1:], t.signatureSlice)
+	return tokenBuffer{
+		buffer:         buffer,
+		headerSlice:    buffer[:encHS],
+		payloadSlice:   buffer[encHS+1 : encHPS],
+		signedSlice:    buffer[:encHPS],
+		signatureSlice: buffer[encHPS+1:],
+	}
+}
+
+// Token is a compact token parsed by Parse. The raw segments are still
+// base64url encoded, and share the memory of Raw
+type Token struct {
+	// Raw is a copy of the parsed token
+	Raw []byte
+	// RawHeader, RawPayload and RawSignature are the encoded segments
+	RawHeader    []byte
+	RawPayload   []byte
+	RawSignature []byte
+	// SigningInput is the header and payload segments the signature is over
+	SigningInput []byte
+
+	Header    JOSEHeader
+	Claims    Claims
+	Payload   []byte
+	Signature []byte
+
+	// KeyID is the key ID of the verifier which verified the signature
+	KeyID string
+	// Algorithm is the algorithm of the alg header
+	Algorithm Algorithm
+	// Verified reports if the signature is verified
+	Verified bool
+
+	// claims is the payload as unmarshaled and validated, which differs from
+	// Payload if the parser normalizes it
+	claims []byte
+}
+
+func newToken(b []byte) (*Token, error) {
+	buf, err := parseTokenBuffer(append([]byte(nil), b...))
+	if err != nil {
+		return nil, err
+	}
+	return &Token{
+		Raw:          buf.buffer,
+		RawHeader:    buf.headerSlice,
+		RawPayload:   buf.payloadSlice,
+		RawSignature: buf.signatureSlice,
+		SigningInput: buf.signedSlice,
+	}, nil
+}
+
+// withSignature encodes the signature into the token, reallocating it if the
+// signature is not of the size it was allocated for, as RSA signatures are
+// the size of the key
+func (t tokenBuffer) withSignature(signature []byte) tokenBuffer {
+	encSS := encodedSegmentLength(len(signature))
+	if encSS != len(t.signatureSlice) {
+		encHS := len(t.headerSlice)
+		encHPS := len(t.signedSlice)
+		buffer := make([]byte, encHPS+1+encSS)
+		copy(buffer, t.buffer[:encHPS+1])
+		t = tokenBuffer{
+			buffer:         buffer,
+			headerSlice:    buffer[:encHS],
+			payloadSlice:   buffer[encHS+1 : encHPS],
+			signedSlice:    buffer[:encHPS],
+			signatureSlice: buffer[encHPS+1:],
+		}
+	}
+	encodeSegment(t.signatureSlice, signature)
+	return t
+}
@@ -0,0 +1,104 @@
+package jwt_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/KalleDK/go-jwt/jwt"
+)
+
+func TestParse(t *testing.T) {
+	key := newTestKey(t, "key")
+	other := newTestKey(t, "key")
+
+	claims := jwt.Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: "1234567890"}}
+	if err := claims.Set("name", "John Doe"); err != nil {
+		t.Fatal(err)
+	}
+	b, err := jwt.Marshal(rand.Reader, claims, key.signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := jwt.Parse(b, key.verifier)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !token.Verified || token.KeyID != "key" || token.Algorithm != jwt.ES256 {
+		t.Errorf("Parse() = verified %v, kid %q, alg %v", token.Verified, token.KeyID, token.Algorithm)
+	}
+	if token.Header.Typ() != "JWT" || token.Claims.Subject != "1234567890" {
+		t.Errorf("Parse() header = %+v, claims = %+v", token.Header, token.Claims)
+	}
+	if name, err := token.Claims.String("name"); err != nil || name != "John Doe" {
+		t.Errorf("Parse() name claim = %q, %v", name, err)
+	}
+	if len(token.Signature) != 64 {
+		t.Errorf("Parse() signature length = %v", len(token.Signature))
+	}
+
+	parts := bytes.Split(b, []byte{'.'})
+	if !bytes.Equal(token.RawHeader, parts[0]) || !bytes.Equal(token.RawPayload, parts[1]) || !bytes.Equal(token.RawSignature, parts[2]) {
+		t.Errorf("Parse() raw segments do not match the token")
+	}
+	if !bytes.Equal(token.SigningInput, b[:bytes.LastIndexByte(b, '.')]) {
+		t.Errorf("Parse() SigningInput = %s", token.SigningInput)
+	}
+	if !bytes.Equal(token.Raw, b) || &token.Raw[0] == &b[0] {
+		t.Errorf("Parse() Raw is not a copy of the token")
+	}
+
+	tests := []struct {
+		name         string
+		token        []byte
+		opts         []jwt.ParserOption
+		wantToken    bool
+		wantVerified bool
+		wantErr      error
+	}{
+		{name: "malformed", token: []byte("a.b"), wantErr: jwt.ErrMalformedToken},
+		{name: "wrong key", token: b, wantToken: true, wantErr: jwt.ErrInvalidSignature},
+		{name: "disallowed algorithm", token: b, opts: []jwt.ParserOption{jwt.WithAlgorithms(jwt.RS256)}, wantToken: true, wantErr: jwt.ErrUnsupportedAlgorithm},
+		{name: "invalid claims", token: b, opts: []jwt.ParserOption{jwt.WithIssuer("issuer")}, wantToken: true, wantVerified: true, wantErr: jwt.ErrInvalidIssuer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := other.verifier
+			if tt.wantVerified {
+				verifier = key.verifier
+			}
+			token, err := jwt.Parse(tt.token, jwt.NewVerifiers(false, verifier), append(tt.opts, jwt.WithClock(time.Now))...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (token != nil) != tt.wantToken {
+				t.Fatalf("Parse() token = %v, wantToken %v", token, tt.wantToken)
+			}
+			if token != nil && token.Verified != tt.wantVerified {
+				t.Errorf("Parse() Verified = %v, want %v", token.Verified, tt.wantVerified)
+			}
+		})
+	}
+}
+
+func TestNewVerifiers(t *testing.T) {
+	key := newTestKey(t, "key")
+	other := newTestKey(t, "other")
+
+	token, err := jwt.Marshal(rand.Reader, testPayload{Subject: "sub"}, other.signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vs := []jwt.Verifier{key.verifier, other.verifier}
+	verifiers := jwt.NewVerifiers(false, vs[:1]...)
+	if vs[1] != other.verifier {
+		t.Fatalf("NewVerifiers() modified the verifiers of the caller")
+	}
+	if _, err := jwt.Parse(token, verifiers); err == nil {
+		t.Errorf("Parse() verified a token signed by a key not in the verifiers")
+	}
+}
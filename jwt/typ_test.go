@@ -0,0 +1,59 @@
+package jwt_test
+
+import (
+	"testing"
+
+	"github.com/KalleDK/go-jwt/jwt"
+)
+
+func TestMatchType(t *testing.T) {
+	tests := []struct {
+		typ      string
+		expected []string
+		want     bool
+	}{
+		{typ: "JWT", expected: []string{"JWT"}, want: true},
+		{typ: "jwt", expected: []string{"JWT"}, want: true},
+		{typ: "application/jwt", expected: []string{"JWT"}, want: true},
+		{typ: "at+jwt", expected: []string{"application/at+JWT"}, want: true},
+		{typ: "at+jwt", expected: []string{"JWT"}, want: false},
+		{typ: "", expected: []string{"JWT", ""}, want: true},
+		{typ: "", expected: []string{"JWT"}, want: false},
+		{typ: "text/jwt", expected: []string{"JWT"}, want: false},
+		{typ: "JWT", expected: nil, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.typ, func(t *testing.T) {
+			if got := jwt.MatchType(tt.typ, tt.expected...); got != tt.want {
+				t.Errorf("MatchType(%q, %q) = %v, want %v", tt.typ, tt.expected, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalTyped(t *testing.T) {
+	key := newTestKey(t, "key")
+
+	tests := []struct {
+		name    string
+		header  string
+		types   []string
+		wantErr bool
+	}{
+		{name: "access token", header: `{"typ":"at+jwt","alg":"ES256"}`, types: []string{"at+jwt"}},
+		{name: "dpop proof as access token", header: `{"typ":"dpop+jwt","alg":"ES256"}`, types: []string{"at+jwt"}, wantErr: true},
+		{name: "untyped allowed", header: `{"alg":"ES256"}`, types: []string{"JWT", ""}},
+		{name: "untyped rejected", header: `{"alg":"ES256"}`, types: []string{"JWT"}, wantErr: true},
+		{name: "unchecked", header: `{"typ":"anything","alg":"ES256"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := signCompact(t, key.signer, tt.header, `{"sub":"1234567890"}`)
+			var got testPayload
+			_, err := jwt.UnmarshalTyped(b, &got, key.verifier, tt.types...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UnmarshalTyped() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
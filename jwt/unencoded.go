@@ -0,0 +1,91 @@
+package jwt
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// unencodedHeader is the header of a JWS with an unencoded payload (RFC 7797)
+type unencodedHeader struct {
+	header
+	Base64   *bool    `json:"b64,omitempty"`
+	Critical []string `json:"crit,omitempty"`
+}
+
+func (h unencodedHeader) Valid() error {
+	if err := h.header.Valid(); err != nil {
+		return err
+	}
+	if h.Base64 == nil || *h.Base64 || !isin("b64", h.Critical) {
+		return ErrMalformedHeader
+	}
+	return nil
+}
+
+func (h unencodedHeader) Understands(name string) bool {
+	return name == "b64"
+}
+
+func isin(k string, s []string) bool {
+	for _, v := range s {
+		if k == v {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalUnencoded signs the raw payload bytes without base64url encoding them
+// (RFC 7797) and returns the JWS with the payload detached. The header carries
+// "b64": false which is listed as critical
+func MarshalUnencoded(rand io.Reader, payload []byte, signer Signer) ([]byte, error) {
+	b64 := false
+	header := unencodedHeader{
+		header:   header{Type: "JWT"},
+		Base64:   &b64,
+		Critical: []string{"b64"},
+	}
+	header.SetAlg(signer.Algorithm())
+	header.SetKid(signer.KeyID())
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+
+	token := newTokenBuffer(len(headerJSON), 0, signer.Algorithm().SignatureSize())
+	encodeSegment(token.headerSlice, headerJSON)
+
+	token = token.attach(len(payload))
+	copy(token.payloadSlice, payload)
+
+	signature, err := signer.Sign(rand, token.signedSlice)
+	if err != nil {
+		return nil, err
+	}
+	token = token.withSignature(signature)
+
+	return token.detached(), nil
+}
+
+// UnmarshalUnencoded verifies a detached JWS with an unencoded payload
+// (RFC 7797) against the raw payload bytes transported out-of-band
+func UnmarshalUnencoded(b []byte, payload []byte, verifiers Verifiers) (string, error) {
+	token, err := parseTokenBuffer(b)
+	if err != nil {
+		return "", err
+	}
+
+	if len(token.payloadSlice) != 0 {
+		return "", ErrPayloadNotDetached
+	}
+
+	var header unencodedHeader
+	if err := unmarshalHeader(token.headerSlice, &header); err != nil {
+		return "", err
+	}
+
+	token = token.attach(len(payload))
+	copy(token.payloadSlice, payload)
+
+	return unmarshalSignature(&header, verifiers, token.signedSlice, token.signatureSlice)
+}
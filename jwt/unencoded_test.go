@@ -0,0 +1,67 @@
+package jwt_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/KalleDK/go-jwt/jwt"
+)
+
+func TestMarshalUnencoded(t *testing.T) {
+	key := newTestKey(t, "obie")
+	body := []byte(`{"Data":{"Amount":"10.00"}}`)
+
+	b, err := jwt.MarshalUnencoded(rand.Reader, body, key.signer)
+	if err != nil {
+		t.Fatalf("MarshalUnencoded() error = %v", err)
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(string(b[:bytes.IndexByte(b, '.')]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var header struct {
+		B64  *bool    `json:"b64"`
+		Crit []string `json:"crit"`
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		t.Fatal(err)
+	}
+	if header.B64 == nil || *header.B64 || len(header.Crit) != 1 || header.Crit[0] != "b64" {
+		t.Errorf("MarshalUnencoded() header = %s", headerJSON)
+	}
+
+	detached, err := jwt.MarshalDetached(rand.Reader, body, key.signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		token   []byte
+		payload []byte
+		wantErr bool
+	}{
+		{name: "valid", token: b, payload: body},
+		{name: "tampered payload", token: b, payload: []byte(`{"Data":{"Amount":"99.00"}}`), wantErr: true},
+		{name: "encoded payload token", token: detached, payload: body, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kid, err := jwt.UnmarshalUnencoded(tt.token, tt.payload, key.verifier)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnmarshalUnencoded() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && kid != "obie" {
+				t.Errorf("UnmarshalUnencoded() kid = %v, want obie", kid)
+			}
+		})
+	}
+
+	if _, err := jwt.UnmarshalDetached(b, body, key.verifier); err == nil {
+		t.Errorf("UnmarshalDetached() of unencoded payload expected error")
+	}
+}
@@ -0,0 +1,111 @@
+package jwt
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+)
+
+var (
+	// ErrMissingCertificateChain is returned when a token has no x5c header
+	ErrMissingCertificateChain = errors.New("missing certificate chain")
+	// ErrCertificateThumbprint is returned when the x5t or x5t#S256 header does
+	// not match the leaf certificate
+	ErrCertificateThumbprint = errors.New("certificate thumbprint mismatch")
+	// ErrMissingTrustRoots is returned when a CertificateResolver has no
+	// Options.Roots, as the system roots trust any public certificate
+	ErrMissingTrustRoots = errors.New("missing trust roots")
+	// ErrMissingKeyUsages is returned when a CertificateResolver has no
+	// Options.KeyUsages
+	ErrMissingKeyUsages = errors.New("missing extended key usages")
+)
+
+// Certificates parses the x5c header, leaf certificate first
+func (h JOSEHeader) Certificates() ([]*x509.Certificate, error) {
+	certs := make([]*x509.Certificate, 0, len(h.X509CertChain))
+	for _, s := range h.X509CertChain {
+		der, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, err
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
+// CertificateResolver resolves the verifier of a token from the leaf of its
+// x5c certificate chain, once the chain has been validated with Options. The
+// certificates following the leaf are used as intermediates, replacing
+// Options.Intermediates. Options.Roots and Options.KeyUsages must be set, the
+// system roots are never used
+type CertificateResolver struct {
+	Options x509.VerifyOptions
+}
+
+func (r CertificateResolver) Resolve(header *JOSEHeader) (Verifiers, error) {
+	if r.Options.Roots == nil {
+		return nil, ErrMissingTrustRoots
+	}
+	if len(r.Options.KeyUsages) == 0 {
+		return nil, ErrMissingKeyUsages
+	}
+
+	certs, err := header.Certificates()
+	if err != nil {
+		return nil, err
+	}
+	if len(certs) == 0 {
+		return nil, ErrMissingCertificateChain
+	}
+	leaf := certs[0]
+
+	if err := checkThumbprints(header, leaf); err != nil {
+		return nil, err
+	}
+
+	opts := r.Options
+	opts.Intermediates = x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+
+	if _, err := leaf.Verify(opts); err != nil {
+		return nil, err
+	}
+
+	if err := checkAlgorithm(header); err != nil {
+		return nil, err
+	}
+	return NewVerifierForKey(header.Alg(), header.Kid(), leaf.PublicKey)
+}
+
+func checkThumbprints(header *JOSEHeader, leaf *x509.Certificate) error {
+	if header.X509Thumbprint != "" {
+		sum := sha1.Sum(leaf.Raw)
+		if !matchThumbprint(header.X509Thumbprint, sum[:]) {
+			return ErrCertificateThumbprint
+		}
+	}
+	if header.X509ThumbprintS256 != "" {
+		sum := sha256.Sum256(leaf.Raw)
+		if !matchThumbprint(header.X509ThumbprintS256, sum[:]) {
+			return ErrCertificateThumbprint
+		}
+	}
+	return nil
+}
+
+func matchThumbprint(encoded string, sum []byte) bool {
+	thumbprint, err := decodeSegment([]byte(encoded))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(thumbprint, sum) == 1
+}
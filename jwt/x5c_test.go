@@ -0,0 +1,216 @@
+package jwt_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/KalleDK/go-jwt/jwt"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCert(t *testing.T, template *x509.Certificate, parent *testCert) testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parentCert, parentKey := template, key
+	if parent != nil {
+		parentCert, parentKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parentCert, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testCert{cert: cert, key: key}
+}
+
+func TestCertificateResolver(t *testing.T) {
+	now := time.Now()
+	root := newTestCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Root"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil)
+	intermediate := newTestCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "Intermediate"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, &root)
+	leaf := newTestCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "issuer.example.com"},
+		DNSNames:     []string{"issuer.example.com"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}, &intermediate)
+	other := newTestCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(4),
+		Subject:               pkix.Name{CommonName: "Other"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root.cert)
+	otherRoots := x509.NewCertPool()
+	otherRoots.AddCert(other.cert)
+
+	chain := []string{
+		base64.StdEncoding.EncodeToString(leaf.cert.Raw),
+		base64.StdEncoding.EncodeToString(intermediate.cert.Raw),
+	}
+	thumbprint := sha256.Sum256(leaf.cert.Raw)
+	signer := jwt.ES256.NewSigner("leaf", leaf.key)
+	codeSigning := []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning}
+
+	tests := []struct {
+		name    string
+		header  jwt.JOSEHeader
+		options x509.VerifyOptions
+		wantErr bool
+		want    error
+	}{
+		{
+			name:    "valid",
+			header:  jwt.JOSEHeader{Type: "JWT", X509CertChain: chain, X509ThumbprintS256: base64.RawURLEncoding.EncodeToString(thumbprint[:])},
+			options: x509.VerifyOptions{Roots: roots, DNSName: "issuer.example.com", KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning}},
+		},
+		{
+			name:    "untrusted root",
+			header:  jwt.JOSEHeader{Type: "JWT", X509CertChain: chain},
+			options: x509.VerifyOptions{Roots: otherRoots, KeyUsages: codeSigning},
+			wantErr: true,
+		},
+		{
+			name:    "missing intermediate",
+			header:  jwt.JOSEHeader{Type: "JWT", X509CertChain: chain[:1]},
+			options: x509.VerifyOptions{Roots: roots, KeyUsages: codeSigning},
+			wantErr: true,
+		},
+		{
+			name:    "expired",
+			header:  jwt.JOSEHeader{Type: "JWT", X509CertChain: chain},
+			options: x509.VerifyOptions{Roots: roots, KeyUsages: codeSigning, CurrentTime: now.Add(2 * time.Hour)},
+			wantErr: true,
+		},
+		{
+			name:    "wrong extended key usage",
+			header:  jwt.JOSEHeader{Type: "JWT", X509CertChain: chain},
+			options: x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}},
+			wantErr: true,
+		},
+		{
+			name:    "wrong name",
+			header:  jwt.JOSEHeader{Type: "JWT", X509CertChain: chain},
+			options: x509.VerifyOptions{Roots: roots, KeyUsages: codeSigning, DNSName: "other.example.com"},
+			wantErr: true,
+		},
+		{
+			name:    "thumbprint mismatch",
+			header:  jwt.JOSEHeader{Type: "JWT", X509CertChain: chain, X509ThumbprintS256: "AAAA"},
+			options: x509.VerifyOptions{Roots: roots, KeyUsages: codeSigning},
+			wantErr: true,
+		},
+		{
+			name:    "system roots",
+			header:  jwt.JOSEHeader{Type: "JWT", X509CertChain: chain},
+			options: x509.VerifyOptions{KeyUsages: codeSigning},
+			wantErr: true,
+			want:    jwt.ErrMissingTrustRoots,
+		},
+		{
+			name:    "any key usage by default",
+			header:  jwt.JOSEHeader{Type: "JWT", X509CertChain: chain},
+			options: x509.VerifyOptions{Roots: roots},
+			wantErr: true,
+			want:    jwt.ErrMissingKeyUsages,
+		},
+		{
+			name:    "missing chain",
+			header:  jwt.JOSEHeader{Type: "JWT"},
+			options: x509.VerifyOptions{Roots: roots, KeyUsages: codeSigning},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := tt.header
+			b, err := jwt.MarshalWithHeader(rand.Reader, testPayload{Subject: "1234567890"}, &header, signer)
+			if err != nil {
+				t.Fatalf("MarshalWithHeader() error = %v", err)
+			}
+
+			var got jwt.JOSEHeader
+			var payload testPayload
+			kid, err := jwt.UnmarshalResolved(b, &payload, &got, jwt.CertificateResolver{Options: tt.options}, jwt.WithAlgorithms(jwt.ES256))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnmarshalResolved() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("UnmarshalResolved() error = %v, want %v", err, tt.want)
+			}
+			if !tt.wantErr && (kid != "leaf" || payload.Subject != "1234567890") {
+				t.Errorf("UnmarshalResolved() kid = %v, payload = %v", kid, payload)
+			}
+		})
+	}
+}
+
+func TestNewVerifierForKey(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		alg     jwt.Algorithm
+		key     interface{}
+		wantErr error
+	}{
+		{name: "matching curve", alg: jwt.ES384, key: &ecKey.PublicKey},
+		{name: "wrong curve", alg: jwt.ES256, key: &ecKey.PublicKey, wantErr: jwt.ErrKeyMismatch},
+		{name: "wrong family", alg: jwt.RS256, key: &ecKey.PublicKey, wantErr: jwt.ErrKeyMismatch},
+		{name: "none", alg: jwt.None, key: nil, wantErr: jwt.ErrUnsupportedAlgorithm},
+		{name: "unknown", alg: 0, key: &ecKey.PublicKey, wantErr: jwt.ErrUnsupportedAlgorithm},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := jwt.NewVerifierForKey(tt.alg, "kid", tt.key)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("NewVerifierForKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}